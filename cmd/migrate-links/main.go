@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"github.com/rs/zerolog/log"
+	"os"
+	"time"
+	"url-shortener/internal/config"
+	"url-shortener/internal/repository/redis"
+)
+
+// Config data model
+type Config struct {
+	Redis redis.Config
+}
+
+// migrate-links converts links stored as `{Key}{code}:full`, `:expire`, `:hits` and `:count`
+// string keys into the single `{Key}{code}:link` hash read by the service.
+func main() {
+	log.Info().Msg("start migrate-links...")
+
+	dryRun := flag.Bool("dry-run", false, "only report the links that would be migrated")
+	flag.Parse()
+
+	if err := run(*dryRun); err != nil {
+		log.Error().Msgf("Unexpected error to migrate links: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run(dryRun bool) error {
+	conf := new(Config)
+	if err := config.ReadConfigFile(conf); err != nil {
+		return err
+	}
+
+	redisHandler := &redis.Handler{}
+	if err := redisHandler.Connect(conf.Redis); err != nil {
+		return err
+	}
+	defer redisHandler.Disconnect()
+
+	migrator := &Migrator{
+		RedisHandler: redisHandler,
+		RedisConfig:  conf.Redis,
+		DryRun:       dryRun,
+		Now:          time.Now,
+	}
+	migrated, err := migrator.Run()
+	log.Info().Msgf("Migrated %v links (dry-run: %v)", migrated, dryRun)
+	return err
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/repository/redis"
+)
+
+const scanCount = 100
+
+// legacyFields are the string keys a link used to be spread across, in `{Key}{code}:{field}`
+var legacyFields = []string{"full", "expire", "hits", "count"}
+
+type Migrator struct {
+	RedisHandler redis.HandlerInterface
+	RedisConfig  redis.Config
+	DryRun       bool
+	Now          func() time.Time
+}
+
+// Run migrates every legacy link and returns how many were converted. Running it again is safe:
+// links already converted are skipped and their leftover legacy keys removed.
+func (m *Migrator) Run() (int, error) {
+	match := fmt.Sprintf("%v*:%v", m.RedisConfig.Key, "full")
+	migrated := 0
+
+	var cursor uint64
+	for {
+		keys, next, err := m.RedisHandler.Scan(context.Background(), cursor, match, scanCount)
+		if err != nil {
+			return migrated, err
+		}
+
+		for _, key := range keys {
+			code := strings.TrimSuffix(strings.TrimPrefix(key, m.RedisConfig.Key), ":full")
+			ok, err := m.migrate(code)
+			if err != nil {
+				return migrated, fmt.Errorf("migrate %v: %v", code, err)
+			}
+			if ok {
+				migrated++
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return migrated, nil
+		}
+	}
+}
+
+func (m *Migrator) migrate(code string) (bool, error) {
+	prefix := fmt.Sprintf("%v%v:", m.RedisConfig.Key, code)
+
+	fields := make(map[string]interface{}, len(legacyFields))
+	keys := make([]string, 0, len(legacyFields))
+	for _, field := range legacyFields {
+		value, err := m.RedisHandler.Get(context.Background(), prefix+field)
+		if err != nil {
+			return false, err
+		}
+		keys = append(keys, prefix+field)
+		if value == "" && field == "count" {
+			value = "0"
+		}
+		fields[field] = value
+	}
+	if fields["full"] == "" {
+		return false, nil
+	}
+
+	expire, _ := strconv.ParseInt(fields["expire"].(string), 0, 64)
+	ttl := m.RedisConfig.TTL(expire, m.Now())
+
+	if m.DryRun {
+		log.Info().Msgf("Would migrate %v (ttl %v)", code, ttl)
+		return true, nil
+	}
+
+	created, err := m.RedisHandler.HMSetNX(context.Background(), prefix+"link", fields, ttl)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		log.Warn().Msgf("Link %v already stored as hash, dropping legacy keys", code)
+	}
+
+	if _, err = m.RedisHandler.Del(context.Background(), keys); err != nil {
+		return false, err
+	}
+	return created, nil
+}
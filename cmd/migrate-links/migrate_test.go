@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockRedis *mockredis.MockHandlerInterface
+)
+
+func setUpMigratorMocking(ctrl *gomock.Controller) Migrator {
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+
+	return Migrator{
+		RedisHandler: mockRedis,
+		RedisConfig:  redis.Config{Key: "shortner:", Retention: 1},
+		Now:          func() time.Time { return time.Unix(4102444800, 0) },
+	}
+}
+
+func (s *TSuite) expectLegacy(code string, full string) {
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:"+code+":full").Return(full, nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:"+code+":expire").Return("4102448400", nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:"+code+":hits").Return("10", nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:"+code+":count").Return("", nil)
+}
+
+func (s *TSuite) TestMigrate_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	Migrator := setUpMigratorMocking(ctrl)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), "shortner:*:full", int64(scanCount)).Return([]string{"shortner:abc:full"}, uint64(5), nil)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(5), "shortner:*:full", int64(scanCount)).Return([]string{"shortner:def:full"}, uint64(0), nil)
+	s.expectLegacy("abc", "https://www.speedtest.net")
+	s.expectLegacy("def", "https://www.example.com")
+
+	mockRedis.EXPECT().HMSetNX(gomock.Any(), "shortner:abc:link", map[string]interface{}{
+		"full":   "https://www.speedtest.net",
+		"expire": "4102448400",
+		"hits":   "10",
+		"count":  "0",
+	}, 25*time.Hour).Return(true, nil)
+	mockRedis.EXPECT().HMSetNX(gomock.Any(), "shortner:def:link", gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRedis.EXPECT().Del(gomock.Any(), []string{"shortner:abc:full", "shortner:abc:expire", "shortner:abc:hits", "shortner:abc:count"}).Return(int64(3), nil)
+	mockRedis.EXPECT().Del(gomock.Any(), []string{"shortner:def:full", "shortner:def:expire", "shortner:def:hits", "shortner:def:count"}).Return(int64(3), nil)
+
+	migrated, err := Migrator.Run()
+	s.Require().NoError(err)
+	s.Assert().Equal(1, migrated)
+}
+
+func (s *TSuite) TestMigrate_DryRun() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	Migrator := setUpMigratorMocking(ctrl)
+	Migrator.DryRun = true
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), gomock.Any(), gomock.Any()).Return([]string{"shortner:abc:full"}, uint64(0), nil)
+	s.expectLegacy("abc", "https://www.speedtest.net")
+
+	migrated, err := Migrator.Run()
+	s.Require().NoError(err)
+	s.Assert().Equal(1, migrated)
+}
+
+func (s *TSuite) TestMigrate_RedisError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	Migrator := setUpMigratorMocking(ctrl)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), gomock.Any(), gomock.Any()).Return([]string{"shortner:abc:full"}, uint64(0), nil)
+	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", errors.New("redis error"))
+
+	_, err := Migrator.Run()
+	s.Assert().Error(err)
+}
@@ -0,0 +1,147 @@
+package blacklisting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/asaskevich/govalidator"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"net/http"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/http/rest"
+)
+
+const fmtError = "%v"
+
+type Service interface {
+	ListPatterns(w http.ResponseWriter, r *http.Request)
+	AddPattern(w http.ResponseWriter, r *http.Request)
+	RemovePattern(w http.ResponseWriter, r *http.Request)
+}
+
+func init() {
+	govalidator.SetFieldsRequiredByDefault(true)
+}
+
+type BlacklistService struct {
+	Blacklist *blacklist.Blacklist
+}
+
+func NewService(list *blacklist.Blacklist) Service {
+	return &BlacklistService{
+		Blacklist: list,
+	}
+}
+
+func (s *BlacklistService) ListPatterns(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("ListPatterns")
+
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    s.Blacklist.List(),
+	})
+
+	return
+}
+
+func (s *BlacklistService) AddPattern(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("AddPattern")
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		msg := fmt.Sprintf("ioutil.ReadAll (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = msg
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	request := new(PatternRequest)
+	if err = json.Unmarshal(bodyBytes, request); err == nil {
+		_, err = govalidator.ValidateStruct(request)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "pattern")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	// step: compile and add pattern
+	err = s.Blacklist.Add(r.Context(), request.Pattern)
+	if errors.Is(err, blacklist.ErrInvalidPattern) {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "pattern")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	fmt.Println("AddPattern : Success")
+	_ = rest.WriteResponse(w, http.StatusCreated, &rest.Response{
+		Code:    201,
+		Message: "Success",
+		Data:    request,
+	})
+
+	return
+}
+
+func (s *BlacklistService) RemovePattern(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("RemovePattern")
+
+	pattern := r.URL.Query().Get("pattern")
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	err := s.Blacklist.Remove(r.Context(), pattern)
+	if err == blacklist.ErrPatternNotFound {
+		msg := fmt.Sprintf("pattern not found (%v)", pattern)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeNotfound["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeNotfound["Message"].(string)
+		rest.WriteResponse(w, http.StatusNotFound, respErr)
+		return
+	} else if err == blacklist.ErrPatternFromFile || err == blacklist.ErrPatternFromConfig {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = err.Error()
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	fmt.Println("RemovePattern : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+	})
+
+	return
+}
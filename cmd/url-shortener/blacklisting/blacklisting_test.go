@@ -0,0 +1,132 @@
+package blacklisting
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-shortener/internal/blacklist"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+func setUpService(s *TSuite) BlacklistService {
+	list, err := blacklist.New(blacklist.Config{Patterns: []string{`malware\.example`}}, nil)
+	s.Require().NoError(err)
+
+	return BlacklistService{
+		Blacklist: list,
+	}
+}
+
+func (s *TSuite) TestList_Success() {
+	BlacklistService := setUpService(s)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/blacklist", nil)
+	BlacklistService.ListPatterns(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `{"pattern":"malware\\.example","source":"config"}`)
+}
+
+func (s *TSuite) TestAdd_InvalidPattern() {
+	BlacklistService := setUpService(s)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/blacklist", strings.NewReader(`{"pattern": "("}`))
+	BlacklistService.AddPattern(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestAdd_Success() {
+	BlacklistService := setUpService(s)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/blacklist", strings.NewReader(`{"pattern": "spam\\.example"}`))
+	BlacklistService.AddPattern(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	_, matched := BlacklistService.Blacklist.Match("http://spam.example")
+	s.Assert().True(matched)
+}
+
+func (s *TSuite) TestRemove_NotFound() {
+	BlacklistService := setUpService(s)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodDelete, "/admin/blacklist?pattern=unknown", nil)
+	BlacklistService.RemovePattern(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
+
+func (s *TSuite) TestRemove_Success() {
+	BlacklistService := setUpService(s)
+	s.Require().NoError(BlacklistService.Blacklist.Add(context.Background(), `spam\.example`))
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodDelete, `/admin/blacklist?pattern=spam%5C.example`, nil)
+	BlacklistService.RemovePattern(w, testRequest)
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+	_, matched := BlacklistService.Blacklist.Match("http://spam.example")
+	s.Assert().False(matched)
+}
+
+func (s *TSuite) TestRemove_ConfigPattern() {
+	BlacklistService := setUpService(s)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodDelete, `/admin/blacklist?pattern=malware%5C.example`, nil)
+	BlacklistService.RemovePattern(w, testRequest)
+
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	_, matched := BlacklistService.Blacklist.Match("http://malware.example")
+	s.Assert().True(matched)
+}
+
+func (s *TSuite) TestAdd_StorageError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	store := mockrepository.NewMockPatternRepository(ctrl)
+	store.EXPECT().ListPatterns(gomock.Any()).Return(nil, nil)
+	store.EXPECT().AddPattern(gomock.Any(), `spam\.example`).Return(errors.New("redis error"))
+	list, err := blacklist.New(blacklist.Config{}, store)
+	s.Require().NoError(err)
+	BlacklistService := BlacklistService{Blacklist: list}
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/blacklist", strings.NewReader(`{"pattern": "spam\\.example"}`))
+	BlacklistService.AddPattern(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
+	_, matched := list.Match("http://spam.example")
+	s.Assert().False(matched)
+}
@@ -0,0 +1,5 @@
+package blacklisting
+
+type PatternRequest struct {
+	Pattern string `json:"pattern" valid:"required"`
+}
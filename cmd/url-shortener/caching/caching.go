@@ -0,0 +1,34 @@
+package caching
+
+import (
+	"fmt"
+	"net/http"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository/cache"
+)
+
+type Service interface {
+	CacheStats(w http.ResponseWriter, r *http.Request)
+}
+
+type CacheService struct {
+	Cache *cache.Repository
+}
+
+func NewService(cached *cache.Repository) Service {
+	return &CacheService{
+		Cache: cached,
+	}
+}
+
+func (s *CacheService) CacheStats(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("CacheStats")
+
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    s.Cache.Stats(),
+	})
+
+	return
+}
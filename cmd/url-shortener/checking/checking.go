@@ -0,0 +1,65 @@
+package checking
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"url-shortener/internal/health"
+	"url-shortener/internal/http/rest"
+)
+
+const fmtError = "%v"
+
+// Service answers the probes of the orchestrator, they are not printed since they run every few seconds
+type Service interface {
+	Healthz(w http.ResponseWriter, r *http.Request)
+	Readyz(w http.ResponseWriter, r *http.Request)
+}
+
+type HealthService struct {
+	Checker *health.Checker
+}
+
+func NewService(checker *health.Checker) Service {
+	return &HealthService{
+		Checker: checker,
+	}
+}
+
+// Healthz tells the process is up, it checks no dependency so a slow Redis does not get the process restarted
+func (s *HealthService) Healthz(w http.ResponseWriter, r *http.Request) {
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    map[string]string{"status": health.StatusOK},
+	})
+
+	return
+}
+
+// Readyz runs the dependency checks and lists the status and latency of each, 503 when one fails or the server drains
+func (s *HealthService) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := s.Checker.Ready(r.Context())
+
+	if report.Status != health.StatusOK {
+		msg := fmt.Sprintf(rest.ErrCodeNotReady["Message"].(string), strings.Join(report.Failing(), ", "))
+		log.Error().Msgf(fmtError, msg)
+		_ = rest.WriteResponse(w, http.StatusServiceUnavailable, &rest.ErrorResponse{
+			Error: rest.Response{
+				Code:    rest.ErrCodeNotReady["Code"].(int),
+				Message: msg,
+				Data:    report,
+			},
+		})
+		return
+	}
+
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    report,
+	})
+
+	return
+}
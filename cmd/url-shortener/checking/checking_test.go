@@ -0,0 +1,77 @@
+package checking
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/health"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+func setUpService(redis error) (HealthService, *health.Checker) {
+	checker := health.NewChecker(time.Second)
+	checker.Register("redis", func(ctx context.Context) error { return redis })
+
+	return HealthService{
+		Checker: checker,
+	}, checker
+}
+
+func (s *TSuite) TestHealthz_Success() {
+	// liveness does not depend on Redis
+	HealthService, _ := setUpService(errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	HealthService.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"data":{"status":"ok"}`)
+}
+
+func (s *TSuite) TestReadyz_Success() {
+	HealthService, _ := setUpService(nil)
+
+	w := httptest.NewRecorder()
+	HealthService.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"data":{"status":"ok","checks":[{"name":"redis","status":"ok","latency_ms":`)
+}
+
+func (s *TSuite) TestReadyz_FailingDependency() {
+	HealthService, _ := setUpService(errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	HealthService.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusServiceUnavailable, w.Code)
+	s.Assert().Contains(string(body), `"code":1023,"message":"Service not ready, failing redis"`)
+	s.Assert().Contains(string(body), `"error":"connection refused"`)
+}
+
+func (s *TSuite) TestReadyz_Draining() {
+	HealthService, checker := setUpService(nil)
+	checker.Drain()
+
+	w := httptest.NewRecorder()
+	HealthService.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusServiceUnavailable, w.Code)
+	s.Assert().Contains(string(body), `"message":"Service not ready, failing shutdown"`)
+}
@@ -2,13 +2,45 @@ package main
 
 import (
 	"time"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/generate/encode"
+	"url-shortener/internal/health"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/cache"
 	"url-shortener/internal/repository/redis"
+	"url-shortener/internal/reputation"
+	"url-shortener/internal/tracing"
+	"url-shortener/internal/urlpolicy"
 )
 
 // Config data model
 type Config struct {
 	Server
-	Redis redis.Config
+	Storage     repository.Config
+	Redis       redis.Config
+	Admin       Admin
+	Blacklist   blacklist.Config
+	Cache       cache.Config
+	Generator   encode.Config
+	Analytics   analytics.Config
+	Idempotency Idempotency
+	// Unlock limits wrong passwords of protected links per short code and client address
+	Unlock    ratelimit.Config
+	RateLimit RateLimit
+	// Domains are the branded domains short links are served from
+	Domains []domain.Config
+	// URLPolicy restricts the destinations of links
+	URLPolicy urlpolicy.Config
+	// Reputation flags links to destinations reported as malicious
+	Reputation reputation.Config
+	QR         QR
+	// Tracing selects where the spans of requests and storage calls are exported
+	Tracing tracing.Config
+	// Health bounds the readiness checks and delays shutdown while readiness fails
+	Health health.Config
 }
 
 // Server data model
@@ -19,3 +51,28 @@ type Server struct {
 	Timeout         time.Duration `conf:"default:5s"`
 	ShutdownTimeout time.Duration `conf:"default:5s"`
 }
+
+// Admin data model
+type Admin struct {
+	Token string
+}
+
+// RateLimit data model, limits are counted per client and route
+type RateLimit struct {
+	// TrustedProxies are the addresses or CIDRs of the proxies whose X-Forwarded-For entries are read
+	TrustedProxies []string
+	Generate       ratelimit.Config
+	Redirect       ratelimit.Config
+}
+
+// QR data model, Size is the default size in pixels of QR code images
+type QR struct {
+	Size    int
+	MaxSize int
+	Cache   cache.Config
+}
+
+// Idempotency data model
+type Idempotency struct {
+	TTL time.Duration
+}
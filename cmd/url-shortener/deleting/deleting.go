@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"github.com/asaskevich/govalidator"
 	"github.com/gorilla/mux"
-	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rs/zerolog/log"
 	"net/http"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
 	"url-shortener/internal/http/rest"
-	"url-shortener/internal/repository/redis"
+	"url-shortener/internal/repository"
 )
 
 const fmtError = "%v"
@@ -22,41 +23,79 @@ func init() {
 }
 
 type StorageService struct {
-	RedisHandler redis.HandlerInterface
-	RedisConfig  redis.Config
+	Repository repository.LinkRepository
+	Domains    *domain.Domains
 }
 
-func NewService(redisHandler *redis.Handler, redisConfig redis.Config) Service {
+func NewService(links repository.LinkRepository, domains *domain.Domains) Service {
 	return &StorageService{
-		RedisHandler: redisHandler,
-		RedisConfig:  redisConfig,
+		Repository: links,
+		Domains:    domains,
 	}
 }
 
+// DeleteUrlShortener deletes a link of the tenant of the caller, any link with the admin scope.
+// The code is looked up on the domain named by the domain parameter, on the domain of the Host header without it.
 func (s *StorageService) DeleteUrlShortener(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("DeleteUrlShortener")
 
 	ctx := r.Context()
-	txn := newrelic.FromContext(ctx)
 	code := mux.Vars(r)["code"]
+	site := s.Domains.Select(r.URL.Query().Get("domain"), r.Host)
 
 	respErr := &rest.ErrorResponse{
 		Error: rest.Response{},
 	}
-	// step: delete key
-	key := fmt.Sprintf("%v%v%v", s.RedisConfig.Key, code, "*")
-	err := s.RedisHandler.Del(key, txn)
-	if err != nil {
-		msg := fmt.Sprintf("redis error (%v)", err)
+
+	// step: the domain parameter names the domain of the code
+	if site == nil {
+		msg := fmt.Sprintf("Invalid parameter (domain %v is not configured)", r.URL.Query().Get("domain"))
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "domain")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+	stored := site.Code(code)
+
+	// step: only the owner deletes a link
+	link, err := s.Repository.Get(ctx, stored)
+	if err == nil && !auth.CanManage(ctx, link.Owner) {
+		msg := fmt.Sprintf("url of another tenant (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeForbidden["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeForbidden["Message"].(string), "the url belongs to another tenant")
+		rest.WriteResponse(w, http.StatusForbidden, respErr)
+		return
+	} else if err != nil && err != repository.ErrNotFound && err != repository.ErrDeleted {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	// step: delete link, the repository leaves a tombstone so visitors get 410
+	err = s.Repository.Delete(ctx, stored)
+	if err == repository.ErrNotFound {
+		msg := fmt.Sprintf("url not found (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeNotfound["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeNotfound["Message"].(string)
+		rest.WriteResponse(w, http.StatusNotFound, respErr)
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
 		log.Error().Msgf(fmtError, msg)
 		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
-		respErr.Error.Message = msg
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
 		rest.WriteResponse(w, http.StatusBadRequest, respErr)
 		return
 	}
 
 	fmt.Println("DeleteUrlShortener : Success")
-	_ = rest.WriteResponse(w, http.StatusGone, &rest.Response{
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
 		Code:    200,
 		Message: "Success",
 	})
@@ -3,12 +3,16 @@ package deleting
 import (
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/suite"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	mockredis "url-shortener/internal/repository/redis/mocks"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
 )
 
 type TSuite struct {
@@ -20,47 +24,133 @@ func TestSuite(t *testing.T) {
 }
 
 var (
-	mockRedis *mockredis.MockHandlerInterface
+	mockRepository *mockrepository.MockLinkRepository
 )
 
 func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
-	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
 
 	return StorageService{
-		RedisHandler: mockRedis,
+		Repository: mockRepository,
 	}
 }
 
-func (s *TSuite) TestDelete_ValidRedisError() {
+// asAdmin authenticates r with the admin token
+func asAdmin(r *http.Request) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), auth.Admin))
+}
+
+func (s *TSuite) TestDelete_StorageError() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Del(gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{}, nil)
+	mockRepository.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodDelete, "/code", nil)
-	StorageService.DeleteUrlShortener(w, testRequest)
+	StorageService.DeleteUrlShortener(w, asAdmin(testRequest))
 
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
 	s.Assert().Equal(http.StatusBadRequest, w.Code)
 	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
 }
 
-func (s *TSuite) TestDelete_Success() {
+func (s *TSuite) TestDelete_NotFound() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, repository.ErrNotFound)
+	mockRepository.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(repository.ErrNotFound)
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodDelete, "/code", nil)
-	StorageService.DeleteUrlShortener(w, testRequest)
+	StorageService.DeleteUrlShortener(w, asAdmin(testRequest))
 
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
-	s.Assert().Equal(http.StatusGone, w.Code)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
+
+func (s *TSuite) TestDelete_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code"}, nil)
+	mockRepository.EXPECT().Delete(gomock.Any(), "code").Return(nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/code", nil), map[string]string{"code": "code"})
+	StorageService.DeleteUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
 	s.Assert().Contains(string(body), `"code":200`)
 }
+
+func (s *TSuite) TestDelete_OtherTenant() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", Owner: "acme"}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/code", nil), map[string]string{"code": "code"})
+	principal := &auth.Principal{KeyID: "k1", Tenant: "globex", Scopes: []string{auth.ScopeDelete}}
+	StorageService.DeleteUrlShortener(w, testRequest.WithContext(auth.NewContext(testRequest.Context(), principal)))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(string(body), `"code":1018`)
+}
+
+func (s *TSuite) TestDelete_OwnLink() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", Owner: "acme"}, nil)
+	mockRepository.EXPECT().Delete(gomock.Any(), "code").Return(nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/code", nil), map[string]string{"code": "code"})
+	principal := &auth.Principal{KeyID: "k1", Tenant: "acme", Scopes: []string{auth.ScopeDelete}}
+	StorageService.DeleteUrlShortener(w, testRequest.WithContext(auth.NewContext(testRequest.Context(), principal)))
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+}
+
+func (s *TSuite) TestDelete_DomainParameter() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	domains, err := domain.New([]domain.Config{{Host: "go.example.com", Namespace: "go"}})
+	s.Require().NoError(err)
+	StorageService.Domains = domains
+	mockRepository.EXPECT().Get(gomock.Any(), "go/code").Return(&repository.Link{Code: "go/code"}, nil)
+	mockRepository.EXPECT().Delete(gomock.Any(), "go/code").Return(nil)
+
+	// the admin api is not served from the branded domain
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/admin/urls/code?domain=go.example.com", nil), map[string]string{"code": "code"})
+	StorageService.DeleteUrlShortener(w, asAdmin(testRequest))
+	s.Assert().Equal(http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	testRequest = mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/admin/urls/code?domain=other.example.com", nil), map[string]string{"code": "code"})
+	StorageService.DeleteUrlShortener(w, asAdmin(testRequest))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
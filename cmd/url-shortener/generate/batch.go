@@ -0,0 +1,317 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"mime"
+	"net/http"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+)
+
+const (
+	// maxBatchItems caps a JSON array batch, NDJSON batches are processed in chunks of ndjsonChunk
+	maxBatchItems = 1000
+	ndjsonChunk   = 100
+	maxLineBytes  = 64 * 1024
+	// maxBatchBytes caps the body of a JSON array batch, maxStreamBytes the body of an NDJSON batch
+	maxBatchBytes  = 2 << 20
+	maxStreamBytes = 16 << 20
+	// maxBatchPasswords caps the protected links of a batch, every password is hashed with bcrypt
+	maxBatchPasswords = 20
+
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+// GenerateBatch creates many links from a JSON array of ShortenerRequest items, or from NDJSON with one
+// item per line. Every item is validated and answered on its own, a failing item does not fail the batch.
+// NDJSON batches are answered with NDJSON, one BatchResult per line, streamed as chunks are stored.
+// Items with reuse_existing are answered 200 with the link created before for their full_url, created links
+// are indexed so later requests can reuse them. Batches can not be replayed with an Idempotency-Key.
+func (s *StorageService) GenerateBatch(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("GenerateBatch")
+
+	ctx := r.Context()
+	host := r.Host
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	if r.Header.Get(idempotencyHeader) != "" {
+		msg := fmt.Sprintf("Invalid %v (not supported by batches)", idempotencyHeader)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), idempotencyHeader)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == contentTypeNDJSON {
+		r.Body = http.MaxBytesReader(w, r.Body, maxStreamBytes)
+		s.generateStream(ctx, w, r, host)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBytes)
+
+	var items []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		msg := fmt.Sprintf("json.Decode (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "body")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+	if len(items) == 0 || len(items) > maxBatchItems {
+		msg := fmt.Sprintf("Invalid batch size (%v)", len(items))
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), fmt.Sprintf("batch size must be between 1 and %v", maxBatchItems))
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	results := s.createBatch(ctx, items, 0, &batch{owner: auth.Tenant(ctx), host: host})
+	data := &BatchResponse{Items: results}
+	for _, result := range results {
+		switch {
+		case result.Error != nil:
+			data.Failed++
+		case result.Status == http.StatusOK:
+			data.Existing++
+		default:
+			data.Created++
+		}
+	}
+
+	fmt.Println("GenerateBatch : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    data,
+	})
+
+	return
+}
+
+// generateStream reads NDJSON items and writes a result line for each of them
+func (s *StorageService) generateStream(ctx context.Context, w http.ResponseWriter, r *http.Request, host string) {
+	w.Header().Set("Content-Type", contentTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+
+	index := 0
+	chunk := make([]json.RawMessage, 0, ndjsonChunk)
+	state := &batch{owner: auth.Tenant(r.Context()), host: host}
+	flush := func() {
+		for _, result := range s.createBatch(ctx, chunk, index, state) {
+			_ = encoder.Encode(result)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		index += len(chunk)
+		chunk = chunk[:0]
+	}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		chunk = append(chunk, append(json.RawMessage(nil), line...))
+		if len(chunk) == ndjsonChunk {
+			flush()
+		}
+	}
+	if len(chunk) > 0 {
+		flush()
+	}
+
+	// a line too long or a broken body ends the stream with an error line
+	if err := scanner.Err(); err != nil {
+		msg := fmt.Sprintf("read ndjson (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		_ = encoder.Encode(&BatchResult{
+			Index:  index,
+			Status: http.StatusBadRequest,
+			Error: &rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "body"),
+			},
+		})
+		return
+	}
+	fmt.Println("GenerateBatch : Success")
+}
+
+// batch is what the chunks of a batch share, owner is the tenant the links are created for
+// and host the host the batch was sent to
+type batch struct {
+	owner string
+	host  string
+	// passwords counts the items of the batch that set a password
+	passwords int
+}
+
+// createBatch validates items and stores the valid ones in one round trip. Generated codes that
+// collide fall back to the retry path of a single request. offset is the index of items[0].
+func (s *StorageService) createBatch(ctx context.Context, items []json.RawMessage, offset int, state *batch) []BatchResult {
+	results := make([]BatchResult, len(items))
+	links := make([]repository.Link, 0, len(items))
+	// positions[j] is the item links[j] comes from, aliases[j] whether its code was chosen by the client
+	positions := make([]int, 0, len(items))
+	aliases := make([]bool, 0, len(items))
+	requests := make([]*ShortenerRequest, 0, len(items))
+
+	// step : decode, validate and pick a first code for every item
+	for i, item := range items {
+		results[i].Index = offset + i
+
+		request := new(ShortenerRequest)
+		if err := json.Unmarshal(item, request); err != nil {
+			results[i].fail(&failure{
+				status: http.StatusBadRequest,
+				response: rest.Response{
+					Code:    rest.ErrCodeBadRequest["Code"].(int),
+					Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "item"),
+				},
+				msg: fmt.Sprintf("json.Unmarshal (%v)", err),
+			})
+			continue
+		}
+		request.owner = state.owner
+		if request.Password != "" {
+			if state.passwords >= maxBatchPasswords {
+				results[i].fail(&failure{
+					status: http.StatusBadRequest,
+					response: rest.Response{
+						Code:    rest.ErrCodeBadRequest["Code"].(int),
+						Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), fmt.Sprintf("password, at most %v items of a batch can set one", maxBatchPasswords)),
+					},
+					msg: fmt.Sprintf("Invalid parameter (more than %v passwords in a batch)", maxBatchPasswords),
+				})
+				continue
+			}
+			state.passwords++
+		}
+		if failed := s.validateNew(request, state.host); failed != nil {
+			results[i].fail(failed)
+			continue
+		}
+
+		existing, failed := s.findExisting(ctx, request, "")
+		if failed != nil {
+			results[i].fail(failed)
+			continue
+		}
+		if existing != nil {
+			results[i].Status = http.StatusOK
+			results[i].Data = shortened(request.domain, existing)
+			continue
+		}
+
+		link := newLink(request)
+		links = append(links, link)
+		positions = append(positions, i)
+		aliases = append(aliases, link.Code != "")
+		requests = append(requests, request)
+	}
+
+	// step : pick the first code of every item without alias at once, a counter reserves them with one increment
+	var inputs []string
+	for j, link := range links {
+		if !aliases[j] {
+			inputs = append(inputs, link.FullURL)
+		}
+	}
+	if len(inputs) > 0 {
+		codes, err := s.Generator.GenerateBatch(ctx, inputs)
+		if failed := storeFailure("", true, err); failed != nil {
+			// only the aliases are left to store
+			kept := 0
+			for j := range links {
+				if !aliases[j] {
+					results[positions[j]].fail(failed)
+					continue
+				}
+				links[kept], positions[kept], aliases[kept], requests[kept] = links[j], positions[j], aliases[j], requests[j]
+				kept++
+			}
+			links, positions, aliases, requests = links[:kept], positions[:kept], aliases[:kept], requests[:kept]
+		} else {
+			next := 0
+			for j := range links {
+				if !aliases[j] {
+					links[j].Code = requests[j].domain.Code(codes[next])
+					next++
+				}
+			}
+		}
+	}
+
+	if len(links) == 0 {
+		return results
+	}
+
+	// step : store every valid item at once
+	created, err := s.Repository.CreateBatch(ctx, links)
+	entries := make([]repository.IndexEntry, 0, len(links))
+	for j, link := range links {
+		i := positions[j]
+		reserved := err == nil && created[j]
+		itemErr := err
+		var existing *repository.Link
+		if err == nil && !reserved && !aliases[j] {
+			// a generated code collided, retry it like a single request would
+			link.Code, existing, itemErr = s.reserveGenerated(ctx, link, requests[j])
+			reserved = true
+		}
+
+		namespace, code := domain.Split(link.Code)
+		if failed := storeFailure(code, reserved, itemErr); failed != nil {
+			results[i].fail(failed)
+			continue
+		}
+		if existing != nil {
+			if !sameParameters(existing, requests[j]) {
+				results[i].fail(replayConflict(requests[j].FullURL))
+				continue
+			}
+			results[i].Status = http.StatusOK
+			results[i].Data = shortened(requests[j].domain, existing)
+			continue
+		}
+		entries = append(entries, repository.IndexEntry{Key: repository.URLKey(link.Owner, namespace, link.FullURL), Code: link.Code, ExpireAt: link.ExpireAt})
+		metrics.LinksCreated.WithLabelValues("batch").Inc()
+		results[i].Status = http.StatusCreated
+		results[i].Data = shortened(requests[j].domain, &link)
+	}
+
+	// step : index the created links under their full_url in one round trip so reuse_existing finds them.
+	// The links are already stored, so index failures are only logged.
+	if len(entries) > 0 {
+		if err := s.Index.PutBatch(ctx, entries); err != nil {
+			log.Warn().Msgf("index full_url of %v links (%v)", len(entries), err)
+		}
+	}
+	return results
+}
+
+func (result *BatchResult) fail(failed *failure) {
+	log.Error().Msgf(fmtError, failed.msg)
+	result.Status = failed.status
+	result.Error = &failed.response
+}
@@ -0,0 +1,231 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestBatch_PerItemResults() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockGenerator.EXPECT().GenerateBatch(gomock.Any(), []string{"https://www.example.com"}).Return([]string{"gen"}, nil)
+	mockRepository.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, links []repository.Link) ([]bool, error) {
+		s.Require().Len(links, 3)
+		s.Assert().Equal("my-alias", links[0].Code)
+		s.Assert().Equal("gen", links[1].Code)
+		s.Assert().Equal("taken", links[2].Code)
+		return []bool{true, true, false}, nil
+	})
+	mockIndex.EXPECT().PutBatch(gomock.Any(), []repository.IndexEntry{
+		{Key: repository.URLKey("", "", "https://www.speedtest.net"), Code: "my-alias", ExpireAt: 4102444800},
+		{Key: repository.URLKey("", "", "https://www.example.com"), Code: "gen", ExpireAt: 4102444800},
+	}).Return(nil)
+
+	mockReqBody := `[
+		{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10},
+		{"full_url": "test", "expire_date": 4102444800, "number_of_hits": 10},
+		{"full_url": "https://www.example.com", "expire_date": 4102444800, "number_of_hits": 10},
+		"not an object",
+		{"short_code": "taken", "full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10}
+	]`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `{"index":0,"status":201,"data":{"short_code":"my-alias"`)
+	s.Assert().Contains(string(body), `{"index":1,"status":400,"error":{"code":1001`)
+	s.Assert().Contains(string(body), `{"index":2,"status":201,"data":{"short_code":"gen"`)
+	s.Assert().Contains(string(body), `{"index":3,"status":400,"error":{"code":1001`)
+	s.Assert().Contains(string(body), `{"index":4,"status":409,"error":{"code":1007`)
+	s.Assert().Contains(string(body), `"created":2,"failed":3,"existing":0`)
+}
+
+func (s *TSuite) TestBatch_GeneratedCollisionRetries() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	gomock.InOrder(
+		mockGenerator.EXPECT().GenerateBatch(gomock.Any(), []string{"https://www.example.com"}).Return([]string{"abc"}, nil),
+		mockRepository.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return([]bool{false}, nil),
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.example.com", 0).Return("abc", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(false, nil),
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.example.com", 1).Return("def", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("def")).Return(true, nil),
+		mockIndex.EXPECT().PutBatch(gomock.Any(), []repository.IndexEntry{
+			{Key: repository.URLKey("", "", "https://www.example.com"), Code: "def", ExpireAt: 4102444800},
+		}).Return(nil),
+	)
+
+	mockReqBody := `[{"full_url": "https://www.example.com", "expire_date": 4102444800, "number_of_hits": 10}]`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `{"index":0,"status":201,"data":{"short_code":"def"`)
+}
+
+func (s *TSuite) TestBatch_StorageError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return(nil, errors.New("redis error"))
+
+	mockReqBody := `[
+		{"short_code": "first", "full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10},
+		{"short_code": "second", "full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10}
+	]`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `{"index":0,"status":400,"error":{"code":1004`)
+	s.Assert().Contains(string(body), `{"index":1,"status":400,"error":{"code":1004`)
+	s.Assert().Contains(string(body), `"created":0,"failed":2`)
+}
+
+func (s *TSuite) TestBatch_InvalidBody() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	for _, mockReqBody := range []string{`{"full_url": "https://www.speedtest.net"}`, `[]`} {
+		w := httptest.NewRecorder()
+		testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+		StorageService.GenerateBatch(w, testRequest)
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code)
+		s.Assert().Contains(string(body), `"code":1001`)
+	}
+}
+
+func (s *TSuite) TestBatch_NDJSON() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).Return([]bool{true, true}, nil)
+	mockIndex.EXPECT().PutBatch(gomock.Any(), gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "first", "full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10}
+
+{"short_code": "second", "full_url": "https://www.example.com", "expire_date": 4102444800, "number_of_hits": 10}
+`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/x-ndjson")
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+	s.Require().Len(lines, 2)
+	s.Assert().Contains(lines[0], `{"index":0,"status":201,"data":{"short_code":"first"`)
+	s.Assert().Contains(lines[1], `{"index":1,"status":201,"data":{"short_code":"second"`)
+}
+
+func (s *TSuite) TestBatch_ReuseExisting() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+
+	mockReqBody := `[{"full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10, "reuse_existing": true}]`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `{"index":0,"status":200,"data":{"short_code":"abc"`)
+	s.Assert().Contains(string(body), `"created":0,"failed":0,"existing":1`)
+}
+
+func (s *TSuite) TestBatch_IdempotencyKeyRejected() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(`[{"full_url": "https://www.speedtest.net", "number_of_hits": 10}]`))
+	testRequest.Header.Set("Idempotency-Key", "key")
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"message":"Invalid parameter Idempotency-Key"`)
+}
+
+func (s *TSuite) TestBatch_BodyTooLarge() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	item := `{"full_url": "https://www.speedtest.net/` + strings.Repeat("a", 2048) + `", "number_of_hits": 10},`
+	mockReqBody := "[" + strings.Repeat(item, maxBatchBytes/len(item)+1) + "]"
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader(mockReqBody))
+	StorageService.GenerateBatch(w, testRequest)
+
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *TSuite) TestBatch_PasswordsCapped() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, links []repository.Link) ([]bool, error) {
+		s.Require().Len(links, maxBatchPasswords)
+		return make([]bool, len(links)), nil
+	})
+
+	items := make([]string, maxBatchPasswords+1)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"short_code": "alias%v", "full_url": "https://www.speedtest.net", "number_of_hits": 10, "password": "secret"}`, i)
+	}
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate/batch", strings.NewReader("["+strings.Join(items, ",")+"]"))
+	StorageService.GenerateBatch(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Contains(string(body), fmt.Sprintf(`{"index":%v,"status":400,"error":{"code":1001,"message":"Invalid parameter password, at most %v items of a batch can set one"}}`, maxBatchPasswords, maxBatchPasswords))
+}
@@ -0,0 +1,88 @@
+package generate
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+)
+
+func setUpDomains(s *TSuite, service *StorageService) {
+	domains, err := domain.New([]domain.Config{{Host: "go.example.com", Namespace: "go", Status: 301}})
+	s.Require().NoError(err)
+	service.Domains = domains
+}
+
+func (s *TSuite) TestGenerate_OnRequestedDomain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	setUpDomains(s, &StorageService)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().Equal("go/my-alias", link.Code)
+		// the domain redirects with 301 unless the request asks otherwise
+		s.Assert().Equal(http.StatusMovedPermanently, link.RedirectStatus)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "go", "https://www.speedtest.net"), "go/my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10, "domain": "go.example.com"}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"my-alias"`)
+	s.Assert().Contains(string(body), `"short_url":"https://go.example.com/my-alias"`)
+}
+
+func (s *TSuite) TestGenerate_GeneratedCodeOnHostDomain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	setUpDomains(s, &StorageService)
+	mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("go/abc")).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "go/abc", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"full_url": "https://www.speedtest.net", "number_of_hits": 10, "redirect_status": 302}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "http://go.example.com/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_url":"https://go.example.com/abc"`)
+	s.Assert().Contains(string(body), `"redirect_status":302`)
+}
+
+func (s *TSuite) TestGenerate_UnknownDomain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	setUpDomains(s, &StorageService)
+
+	mockReqBody := `{"full_url": "https://www.speedtest.net", "number_of_hits": 10, "domain": "other.example.com"}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+	s.Assert().Contains(string(body), "domain")
+}
@@ -0,0 +1,87 @@
+package generate
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"url-shortener/internal/http/rest"
+)
+
+// ExpiryPolicy fills in and bounds the expiry of links. A zero Default creates links without expire_date
+// or expires_in that never expire, a zero Max lets links expire as late as they like or never.
+type ExpiryPolicy struct {
+	Default time.Duration
+	Max     time.Duration
+}
+
+// validateNew picks the domain of a new link requested on host and resolves its expiry before applying
+// the rules every stored link must pass, then hashes its password
+func (s *StorageService) validateNew(request *ShortenerRequest, host string) *failure {
+	if failed := s.pickDomain(request, host); failed != nil {
+		return failed
+	}
+	if failed := s.resolveExpiry(request, time.Now()); failed != nil {
+		return failed
+	}
+	if failed := s.validate(request); failed != nil {
+		return failed
+	}
+	return hashPassword(request)
+}
+
+// resolveExpiry turns expires_in, or the default expiry when the request has none, into expire_date
+func (s *StorageService) resolveExpiry(request *ShortenerRequest, now time.Time) *failure {
+	var ttl time.Duration
+	switch {
+	case request.ExpiresIn != "" && request.ExpireDate != 0:
+		return expiryFailure("expire_date and expires_in can not be combined")
+	case request.ExpiresIn != "":
+		value, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil || value <= 0 {
+			return expiryFailure(fmt.Sprintf("expires_in must be a positive duration such as 72h (%v)", request.ExpiresIn))
+		}
+		ttl = value
+	case request.ExpireDate == 0:
+		ttl = s.Expiry.Default
+		if request.domain != nil && request.domain.Expiry > 0 {
+			ttl = request.domain.Expiry
+		}
+	default:
+		return nil
+	}
+
+	if ttl > 0 {
+		request.ExpireDate = now.Add(ttl).Unix()
+		request.relativeExpiry = true
+	}
+	return nil
+}
+
+// checkMaxExpiry rejects expiries later than the policy allows, never expiring included
+func (s *StorageService) checkMaxExpiry(expireDate int64, now time.Time) *failure {
+	if s.Expiry.Max <= 0 {
+		return nil
+	}
+	if expireDate != 0 && expireDate <= now.Add(s.Expiry.Max).Unix() {
+		return nil
+	}
+	return &failure{
+		status: http.StatusBadRequest,
+		response: rest.Response{
+			Code:    rest.ErrCodeDateMax["Code"].(int),
+			Message: fmt.Sprintf(rest.ErrCodeDateMax["Message"].(string), s.Expiry.Max),
+		},
+		msg: fmt.Sprintf("Invalid expire_date :%v later than %v from now", expireDate, s.Expiry.Max),
+	}
+}
+
+func expiryFailure(reason string) *failure {
+	return &failure{
+		status: http.StatusBadRequest,
+		response: rest.Response{
+			Code:    rest.ErrCodeBadRequest["Code"].(int),
+			Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "expires_in"),
+		},
+		msg: fmt.Sprintf("Invalid parameter (%v)", reason),
+	}
+}
@@ -0,0 +1,151 @@
+package generate
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGenerate_WithoutExpiry() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().Equal(int64(0), link.ExpireAt)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", int64(0)).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().NotContains(string(body), `"expire_date"`)
+}
+
+func (s *TSuite) TestGenerate_DefaultExpiry() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Expiry = ExpiryPolicy{Default: 24 * time.Hour}
+	want := time.Now().Add(24 * time.Hour).Unix()
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().InDelta(want, link.ExpireAt, 2)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"expire_date":`)
+}
+
+func (s *TSuite) TestGenerate_ExpiresIn() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Expiry = ExpiryPolicy{Default: 24 * time.Hour, Max: 7 * 24 * time.Hour}
+	want := time.Now().Add(72 * time.Hour).Unix()
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().InDelta(want, link.ExpireAt, 2)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "expires_in": "72h", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+}
+
+func (s *TSuite) TestGenerate_ExpiresInInvalid() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	for _, mockReqBody := range []string{
+		`{"full_url": "https://www.speedtest.net", "expires_in": "3 days", "number_of_hits": 10}`,
+		`{"full_url": "https://www.speedtest.net", "expires_in": "-1h", "number_of_hits": 10}`,
+		`{"full_url": "https://www.speedtest.net", "expires_in": "1h", "expire_date": 4102444800, "number_of_hits": 10}`,
+	} {
+		w := httptest.NewRecorder()
+		testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+		StorageService.GenerateUrlShortener(w, testRequest)
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code, mockReqBody)
+		s.Assert().Contains(string(body), `"code":1001`, mockReqBody)
+	}
+}
+
+func (s *TSuite) TestGenerate_MaxExpiry() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Expiry = ExpiryPolicy{Max: 7 * 24 * time.Hour}
+
+	for _, mockReqBody := range []string{
+		`{"full_url": "https://www.speedtest.net", "expire_date": 4102444800, "number_of_hits": 10}`,
+		`{"full_url": "https://www.speedtest.net", "expires_in": "200h", "number_of_hits": 10}`,
+		// never expiring is the latest expiry of all
+		`{"full_url": "https://www.speedtest.net", "number_of_hits": 10}`,
+	} {
+		w := httptest.NewRecorder()
+		testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+		StorageService.GenerateUrlShortener(w, testRequest)
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code, mockReqBody)
+		s.Assert().Contains(string(body), `"code":1014`, mockReqBody)
+	}
+}
+
+func (s *TSuite) TestGenerate_RelativeExpiryReplayed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// created a while ago with the same expires_in, its expire_date is earlier than a fresh one
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.IdempotencyKey("", "key-1")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: time.Now().Add(71 * time.Hour).Unix(), MaxHits: 10}, nil)
+	mockIndex.EXPECT().Claim(gomock.Any(), repository.IdempotencyKey("", "key-1"), "abc", gomock.Any()).Return("abc", nil)
+
+	mockReqBody := `{"full_url": "https://www.speedtest.net", "expires_in": "72h", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Idempotency-Key", "key-1")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"abc"`)
+}
@@ -1,50 +1,91 @@
 package generate
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/asaskevich/govalidator"
 	_ "github.com/gin-gonic/gin"
 	_ "github.com/gorilla/mux"
-	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rs/zerolog/log"
 	"io/ioutil"
-	"math/big"
 	"net/http"
 	"strings"
 	"time"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/domain"
 	"url-shortener/internal/generate/encode"
 	"url-shortener/internal/http/rest"
-	"url-shortener/internal/repository/redis"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/reputation"
+	"url-shortener/internal/urlpolicy"
 )
 
-const fmtError = "%v"
+const (
+	fmtError = "%v"
+
+	idempotencyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLen = 128
+)
+
+var errNoFreeCode = errors.New("no free short code left after retries")
 
 type Service interface {
 	GenerateUrlShortener(w http.ResponseWriter, r *http.Request)
+	GenerateBatch(w http.ResponseWriter, r *http.Request)
+	UpdateUrlShortener(w http.ResponseWriter, r *http.Request)
 }
 
 func init() {
 	govalidator.SetFieldsRequiredByDefault(true)
+	govalidator.TagMap["alias"] = isValidAlias
 }
 
 type StorageService struct {
-	RedisHandler redis.HandlerInterface
-	RedisConfig  redis.Config
+	Repository      repository.LinkRepository
+	Blacklist       *blacklist.Blacklist
+	Generator       encode.Generator
+	GeneratorConfig encode.Config
+	Index           repository.IndexRepository
+	// IdempotencyTTL is how long an Idempotency-Key answers with the link it created
+	IdempotencyTTL time.Duration
+	Expiry         ExpiryPolicy
+	Domains        *domain.Domains
+	// Policy normalizes destination urls and rejects unsafe ones
+	Policy *urlpolicy.Policy
+	// Reputation refuses destinations reported as malicious, nil skips the check
+	Reputation reputation.Checker
+	// QRSize is the size in pixels of the QR codes added to responses, 0 for qrcode.DefaultSize
+	QRSize int
 }
 
-func NewService(redisHandler *redis.Handler, redisConfig redis.Config) Service {
+func NewService(links repository.LinkRepository, list *blacklist.Blacklist, generator encode.Generator, generatorConfig encode.Config, index repository.IndexRepository, idempotencyTTL time.Duration, expiry ExpiryPolicy, domains *domain.Domains, policy *urlpolicy.Policy, checker reputation.Checker, qrSize int) Service {
 	return &StorageService{
-		RedisHandler: redisHandler,
-		RedisConfig:  redisConfig,
+		Repository:      links,
+		Blacklist:       list,
+		Generator:       generator,
+		GeneratorConfig: generatorConfig,
+		Index:           index,
+		IdempotencyTTL:  idempotencyTTL,
+		Expiry:          expiry,
+		Domains:         domains,
+		Policy:          policy,
+		Reputation:      checker,
+		QRSize:          qrSize,
 	}
 }
 
+// GenerateUrlShortener creates a link. A request repeated with the same Idempotency-Key header, or with
+// reuse_existing for a full_url shortened before, is answered 200 with the existing link instead of
+// overwriting it, and 409 when its parameters differ from those of the existing link. Links are created on
+// the configured domain named by domain, or on the domain of the Host header.
 func (s *StorageService) GenerateUrlShortener(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("GenerateUrlShortener")
 
 	ctx := r.Context()
-	txn := newrelic.FromContext(ctx)
 
 	respErr := &rest.ErrorResponse{
 		Error: rest.Response{},
@@ -68,65 +109,495 @@ func (s *StorageService) GenerateUrlShortener(w http.ResponseWriter, r *http.Req
 		respErr.Error.Message = msg
 		return
 	}
+	request.owner = auth.Tenant(ctx)
 
 	// Step : validate request
-	_, err = govalidator.ValidateStruct(request)
-	if err != nil {
-		var errList []string
-		for i := range govalidator.ErrorsByField(err) {
-			errList = append(errList, i)
-		}
-		msg := fmt.Sprintf("Invalid parameter (%v)", err)
-		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
-		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), strings.Join(errList, ","))
-		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+	if failed := s.validateNew(request, r.Host); failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
 		return
 	}
 
-	if request.ExpireDate <= time.Now().Unix() {
-		msg := fmt.Sprintf("Invalid expire_date :%v <= %v", request.ExpireDate, time.Now().Unix())
+	idempotencyKey := r.Header.Get(idempotencyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLen {
+		msg := fmt.Sprintf("Invalid %v (longer than %v)", idempotencyHeader, maxIdempotencyKeyLen)
 		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeDate["Code"].(int)
-		respErr.Error.Message = rest.ErrCodeDate["Message"].(string)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), idempotencyHeader)
 		rest.WriteResponse(w, http.StatusBadRequest, respErr)
 		return
 	}
 
-	// step : generate shorten url
-	urlHashBytes := encode.Sha256Of(request.FullURL)
-	generatedNumber := new(big.Int).SetBytes(urlHashBytes).Uint64()
-	finalString := encode.Base58Encoded([]byte(fmt.Sprintf("%d", generatedNumber)))
-
-	// step : set url on redis
-	additional := make(map[string]interface{})
-	additional[fmt.Sprintf("%v%v:%v", s.RedisConfig.Key, finalString, "expire")] = request.ExpireDate
-	additional[fmt.Sprintf("%v%v:%v", s.RedisConfig.Key, finalString, "full")] = request.FullURL
-	additional[fmt.Sprintf("%v%v:%v", s.RedisConfig.Key, finalString, "hits")] = request.NumberOfHits
+	// step : answer a repeated request with the link it already created
+	existing, failed := s.findExisting(ctx, request, idempotencyKey)
+	if failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+	if existing != nil {
+		s.remember(ctx, *existing, request, idempotencyKey, false)
+		fmt.Println("GenerateUrlShortener : Existing")
+		_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+			Code:    200,
+			Message: "Success",
+			Data:    s.withQR(request, shortened(request.domain, existing)),
+		})
+		return
+	}
 
-	for k, v := range additional {
-		err = s.RedisHandler.Set(k, v, 0, txn)
-		if err != nil {
-			msg := fmt.Sprintf("error redis (%v)", err)
-			log.Error().Msgf(fmtError, msg)
-			respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
-			respErr.Error.Message = fmt.Sprintf(rest.ErrCodeRedis["Message"].(string), err)
-			rest.WriteResponse(w, http.StatusBadRequest, respErr)
+	// step : store the link under the custom alias or a generated short code
+	link := newLink(request)
+	reserved := true
+	if link.Code != "" {
+		// the first request wins the alias
+		reserved, err = s.Repository.Create(ctx, link)
+	} else {
+		link.Code, existing, err = s.reserveGenerated(ctx, link, request)
+	}
+	if failed := storeFailure(request.ShortCode, reserved, err); failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+	if existing != nil {
+		// the generated code already holds the url, it is reused like an indexed link
+		if !sameParameters(existing, request) {
+			failed := replayConflict(request.FullURL)
+			log.Error().Msgf(fmtError, failed.msg)
+			rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+			return
 		}
+		s.remember(ctx, *existing, request, idempotencyKey, false)
+		fmt.Println("GenerateUrlShortener : Existing")
+		_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+			Code:    200,
+			Message: "Success",
+			Data:    s.withQR(request, shortened(request.domain, existing)),
+		})
+		return
 	}
 
-	host := fmt.Sprintf("http://%v/", r.Host)
-	data := &ShortenerResponse{
-		ShortCode: request.ShortCode,
-		FullURL:   request.FullURL,
-		ShortURL:  host + finalString,
+	// step : index the link, a concurrent request with the same Idempotency-Key may have won
+	existing, failed = s.remember(ctx, link, request, idempotencyKey, true)
+	if failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+	if existing != nil {
+		fmt.Println("GenerateUrlShortener : Existing")
+		_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+			Code:    200,
+			Message: "Success",
+			Data:    s.withQR(request, shortened(request.domain, existing)),
+		})
+		return
 	}
+
 	fmt.Println("GenerateUrlShortener : Success")
+	metrics.LinksCreated.WithLabelValues("generate").Inc()
 	_ = rest.WriteResponse(w, http.StatusCreated, &rest.Response{
 		Code:    302,
 		Message: "Success",
-		Data:    data,
+		Data:    s.withQR(request, shortened(request.domain, &link)),
 	})
 
 	return
 }
+
+// failure is a request answered with an error, msg is logged
+type failure struct {
+	status   int
+	response rest.Response
+	msg      string
+}
+
+// pickDomain sets the domain a link is created on, the default redirect status of the domain applies
+func (s *StorageService) pickDomain(request *ShortenerRequest, host string) *failure {
+	request.domain = s.Domains.ForHost(host)
+	if request.Domain != "" {
+		request.domain = s.Domains.Named(request.Domain)
+	}
+	if request.domain == nil {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "domain"),
+			},
+			msg: fmt.Sprintf("Invalid parameter (domain %v is not configured)", request.Domain),
+		}
+	}
+	if request.RedirectStatus == 0 {
+		request.RedirectStatus = request.domain.RedirectStatus
+	}
+	return nil
+}
+
+// validate applies the rules every stored link must pass, an expire_date of 0 never expires.
+// The full_url of request is normalized by the url policy.
+func (s *StorageService) validate(request *ShortenerRequest) *failure {
+	// the policy runs first so a disallowed scheme such as javascript: is answered as such, not as a bad url,
+	// the url is still validated as sent and only normalized once every rule passed
+	fullURL := request.FullURL
+	if request.FullURL != "" {
+		normalized, failed := s.checkPolicy(request)
+		if failed != nil {
+			return failed
+		}
+		fullURL = normalized
+	}
+
+	_, err := govalidator.ValidateStruct(request)
+	if err != nil {
+		var errList []string
+		for i := range govalidator.ErrorsByField(err) {
+			errList = append(errList, i)
+		}
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), strings.Join(errList, ",")),
+			},
+			msg: fmt.Sprintf("Invalid parameter (%v)", err),
+		}
+	}
+
+	if request.ExpireDate != 0 && request.ExpireDate <= time.Now().Unix() {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeDate["Code"].(int),
+				Message: rest.ErrCodeDate["Message"].(string),
+			},
+			msg: fmt.Sprintf("Invalid expire_date :%v <= %v", request.ExpireDate, time.Now().Unix()),
+		}
+	}
+	if failed := s.checkMaxExpiry(request.ExpireDate, time.Now()); failed != nil {
+		return failed
+	}
+
+	if len(request.Password) > maxPasswordLen {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "password"),
+			},
+			msg: fmt.Sprintf("Invalid parameter (password longer than %v bytes)", maxPasswordLen),
+		}
+	}
+
+	if request.RedirectStatus != 0 && !redirectStatuses[request.RedirectStatus] {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "redirect_status"),
+			},
+			msg: fmt.Sprintf("Invalid parameter (redirect_status %v)", request.RedirectStatus),
+		}
+	}
+
+	request.FullURL = fullURL
+	if pattern, matched := s.Blacklist.Match(request.FullURL); matched {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeURLInvalid["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeURLInvalid["Message"].(string), request.FullURL),
+			},
+			msg: fmt.Sprintf("blacklisted url (%v matches %v)", request.FullURL, pattern),
+		}
+	}
+	return s.checkReputation(request.FullURL)
+}
+
+// checkReputation refuses urls whose verdict is to refuse them, confirmed matches and prefix matches when
+// the prefix action is refuse. Other matches are let through with a warning, the sweeper flags them when
+// their action is flag. Urls are let through when the check fails and left to the sweeper.
+func (s *StorageService) checkReputation(fullURL string) *failure {
+	if s.Reputation == nil {
+		return nil
+	}
+
+	verdict, err := s.Reputation.Check(fullURL)
+	if err != nil {
+		log.Warn().Msgf("reputation check failed (%v)", err)
+		return nil
+	}
+	if verdict.Threat == "" {
+		return nil
+	}
+	threat := verdict.Threat
+	if !verdict.Refused() {
+		log.Warn().Msgf("unconfirmed reputation match (%v matches a %v hash prefix)", fullURL, threat)
+		return nil
+	}
+	return &failure{
+		status: http.StatusBadRequest,
+		response: rest.Response{
+			Code:    rest.ErrCodeURLFlagged["Code"].(int),
+			Message: fmt.Sprintf(rest.ErrCodeURLFlagged["Message"].(string), threat),
+		},
+		msg: fmt.Sprintf("flagged url (%v reported as %v)", fullURL, threat),
+	}
+}
+
+// checkPolicy returns the normalized full_url of request, it may not point back to the domain of the link
+func (s *StorageService) checkPolicy(request *ShortenerRequest) (string, *failure) {
+	var own []string
+	if request.domain != nil {
+		own = append(own, request.domain.Host)
+	}
+	fullURL, err := s.Policy.Check(request.FullURL, own...)
+	if err == nil {
+		return fullURL, nil
+	}
+
+	errCode := rest.ErrCodeURLInvalid
+	subject := request.FullURL
+	if violation, ok := err.(*urlpolicy.Violation); ok {
+		switch violation.Rule {
+		case urlpolicy.RuleScheme:
+			errCode, subject = rest.ErrCodeURLScheme, violation.Detail
+		case urlpolicy.RulePrivate:
+			errCode, subject = rest.ErrCodeURLPrivate, violation.Detail
+		case urlpolicy.RuleOwnHost:
+			errCode, subject = rest.ErrCodeURLLoop, violation.Detail
+		}
+	}
+	return "", &failure{
+		status: http.StatusBadRequest,
+		response: rest.Response{
+			Code:    errCode["Code"].(int),
+			Message: fmt.Sprintf(errCode["Message"].(string), subject),
+		},
+		msg: fmt.Sprintf("unsafe url (%v)", err),
+	}
+}
+
+// storeFailure maps the outcome of storing code to the error answered, nil when it was stored
+func storeFailure(code string, reserved bool, err error) *failure {
+	switch {
+	case err == errNoFreeCode:
+		return &failure{
+			status: http.StatusServiceUnavailable,
+			response: rest.Response{
+				Code:    rest.ErrCodeGenerate["Code"].(int),
+				Message: rest.ErrCodeGenerate["Message"].(string),
+			},
+			msg: fmt.Sprintf("generate short code (%v)", err),
+		}
+	case err != nil:
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeRedis["Code"].(int),
+				Message: rest.ErrCodeRedis["Message"].(string),
+			},
+			msg: fmt.Sprintf("error storage (%v)", err),
+		}
+	case !reserved:
+		return &failure{
+			status: http.StatusConflict,
+			response: rest.Response{
+				Code:    rest.ErrCodeConflict["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeConflict["Message"].(string), code),
+			},
+			msg: fmt.Sprintf("short_code already taken (%v)", code),
+		}
+	}
+	return nil
+}
+
+// findExisting returns the live link created by an earlier request with the same Idempotency-Key,
+// or for the same full_url when the request asks to reuse it
+func (s *StorageService) findExisting(ctx context.Context, request *ShortenerRequest, idempotencyKey string) (*repository.Link, *failure) {
+	if idempotencyKey != "" {
+		link, err := s.indexed(ctx, repository.IdempotencyKey(request.owner, idempotencyKey))
+		if err != nil {
+			return nil, storeFailure("", true, err)
+		}
+		if link != nil {
+			if !sameParameters(link, request) {
+				return nil, replayConflict(idempotencyHeader + " " + idempotencyKey)
+			}
+			return link, nil
+		}
+	}
+
+	if request.ReuseExisting {
+		link, err := s.indexed(ctx, repository.URLKey(request.owner, request.domain.Namespace, request.FullURL))
+		if err != nil {
+			return nil, storeFailure("", true, err)
+		}
+		// the code may have been taken over by another url since it was indexed
+		if link != nil && link.FullURL == request.FullURL {
+			if !sameParameters(link, request) {
+				return nil, replayConflict(request.FullURL)
+			}
+			return link, nil
+		}
+	}
+	return nil, nil
+}
+
+// indexed returns the link stored under key in the index, nil when it is missing, deleted or expired
+func (s *StorageService) indexed(ctx context.Context, key string) (*repository.Link, error) {
+	code, err := s.Index.Lookup(ctx, key)
+	if err == repository.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	link, err := s.Repository.Get(ctx, code)
+	if err == repository.ErrNotFound || err == repository.ErrDeleted {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if link.ExpireAt > 0 && link.ExpireAt <= time.Now().Unix() {
+		return nil, nil
+	}
+	return link, nil
+}
+
+// remember indexes link under its full_url when it was just created and under idempotencyKey.
+// The link is already stored, so index failures are only logged. When another request claimed
+// idempotencyKey first, the link of that request is returned and link is deleted.
+func (s *StorageService) remember(ctx context.Context, link repository.Link, request *ShortenerRequest, idempotencyKey string, created bool) (*repository.Link, *failure) {
+	if created {
+		namespace, _ := domain.Split(link.Code)
+		if err := s.Index.Put(ctx, repository.URLKey(link.Owner, namespace, link.FullURL), link.Code, link.ExpireAt); err != nil {
+			log.Warn().Msgf("index full_url of %v (%v)", link.Code, err)
+		}
+	}
+	if idempotencyKey == "" {
+		return nil, nil
+	}
+
+	key := repository.IdempotencyKey(link.Owner, idempotencyKey)
+	code, err := s.Index.Claim(ctx, key, link.Code, time.Now().Add(s.IdempotencyTTL).Unix())
+	if err != nil {
+		log.Warn().Msgf("index %v of %v (%v)", idempotencyHeader, link.Code, err)
+		return nil, nil
+	}
+	if code == link.Code || !created {
+		return nil, nil
+	}
+
+	winner, err := s.indexed(ctx, key)
+	if err != nil {
+		return nil, storeFailure(code, true, err)
+	}
+	if winner == nil {
+		// the link of the other request is gone already, this one takes the key over
+		if err = s.Index.Put(ctx, key, link.Code, time.Now().Add(s.IdempotencyTTL).Unix()); err != nil {
+			log.Warn().Msgf("index %v of %v (%v)", idempotencyHeader, link.Code, err)
+		}
+		return nil, nil
+	}
+
+	if err = s.Repository.Delete(ctx, link.Code); err != nil {
+		log.Warn().Msgf("delete duplicate link %v (%v)", link.Code, err)
+	}
+	if !sameParameters(winner, request) {
+		return nil, replayConflict(idempotencyHeader + " " + idempotencyKey)
+	}
+	return winner, nil
+}
+
+// sameParameters reports whether link is what request asks for, on the domain it asks for
+func sameParameters(link *repository.Link, request *ShortenerRequest) bool {
+	namespace, _ := domain.Split(link.Code)
+	return link.FullURL == request.FullURL && link.Owner == request.owner && namespace == request.domain.Namespace &&
+		(request.relativeExpiry || link.ExpireAt == request.ExpireDate) &&
+		samePassword(link.PasswordHash, request.Password) &&
+		link.StatusCode() == (repository.Link{RedirectStatus: request.RedirectStatus}).StatusCode() &&
+		link.MaxHits == int64(request.NumberOfHits) &&
+		(request.ShortCode == "" || request.domain.Code(request.ShortCode) == link.Code)
+}
+
+func replayConflict(subject string) *failure {
+	return &failure{
+		status: http.StatusConflict,
+		response: rest.Response{
+			Code:    rest.ErrCodeReplayConflict["Code"].(int),
+			Message: fmt.Sprintf(rest.ErrCodeReplayConflict["Message"].(string), subject),
+		},
+		msg: fmt.Sprintf("replayed with different parameters (%v)", subject),
+	}
+}
+
+// shortened describes link on d, the domain it was created on
+func shortened(d *domain.Domain, link *repository.Link) *ShortenerResponse {
+	_, code := domain.Split(link.Code)
+	return &ShortenerResponse{
+		ShortCode:  code,
+		FullURL:    link.FullURL,
+		ShortURL:   d.ShortURL(code),
+		ExpireDate: link.ExpireAt,
+		Protected:  link.PasswordHash != "",
+
+		RedirectStatus: link.StatusCode(),
+	}
+}
+
+func newLink(request *ShortenerRequest) repository.Link {
+	code := ""
+	if request.ShortCode != "" {
+		code = request.domain.Code(request.ShortCode)
+	}
+	return repository.Link{
+		Code:      code,
+		FullURL:   request.FullURL,
+		ExpireAt:  request.ExpireDate,
+		MaxHits:   int64(request.NumberOfHits),
+		CreatedAt: time.Now().Unix(),
+
+		PasswordHash:   request.passwordHash,
+		RedirectStatus: request.RedirectStatus,
+		Owner:          request.owner,
+	}
+}
+
+// reserveGenerated asks the generator for codes until one is free on the domain of request and returns the
+// stored code. When request asks to reuse an existing link and a generated code already holds the same public
+// url of the same tenant with the same redirect status, that link is returned untouched instead. Any other
+// taken code is a collision, protected links never share a code.
+func (s *StorageService) reserveGenerated(ctx context.Context, link repository.Link, request *ShortenerRequest) (string, *repository.Link, error) {
+	for attempt := 0; attempt <= s.GeneratorConfig.Retries(); attempt++ {
+		generated, err := s.Generator.Generate(ctx, link.FullURL, attempt)
+		if err != nil {
+			return "", nil, err
+		}
+		code := request.domain.Code(generated)
+
+		link.Code = code
+		reserved, err := s.Repository.Create(ctx, link)
+		if err != nil {
+			return "", nil, err
+		}
+		if reserved {
+			return code, nil, nil
+		}
+
+		if request.ReuseExisting {
+			existing, err := s.Repository.Get(ctx, code)
+			if err != nil && err != repository.ErrNotFound && err != repository.ErrDeleted {
+				return "", nil, err
+			}
+			if existing != nil && existing.FullURL == link.FullURL && existing.Owner == link.Owner &&
+				existing.StatusCode() == link.StatusCode() &&
+				existing.PasswordHash == "" && link.PasswordHash == "" {
+				return code, existing, nil
+			}
+		}
+		log.Warn().Msgf("short code collision (%v), attempt %v", code, attempt)
+	}
+	return "", nil, errNoFreeCode
+}
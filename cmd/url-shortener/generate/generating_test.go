@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
@@ -9,7 +10,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
-	mockredis "url-shortener/internal/repository/redis/mocks"
+	"time"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/generate/encode"
+	mockencode "url-shortener/internal/generate/encode/mocks"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
 )
 
 type TSuite struct {
@@ -21,17 +27,41 @@ func TestSuite(t *testing.T) {
 }
 
 var (
-	mockRedis *mockredis.MockHandlerInterface
+	mockRepository *mockrepository.MockLinkRepository
+	mockGenerator  *mockencode.MockGenerator
+	mockIndex      *mockrepository.MockIndexRepository
 )
 
 func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
-	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
+	mockGenerator = mockencode.NewMockGenerator(ctrl)
+	mockIndex = mockrepository.NewMockIndexRepository(ctrl)
 
 	return StorageService{
-		RedisHandler: mockRedis,
+		Repository:      mockRepository,
+		Generator:       mockGenerator,
+		GeneratorConfig: encode.Config{MaxRetries: 2},
+		Index:           mockIndex,
+		IdempotencyTTL:  time.Hour,
 	}
 }
 
+// codeMatcher matches a repository.Link by its short code
+type codeMatcher string
+
+func hasCode(code string) gomock.Matcher {
+	return codeMatcher(code)
+}
+
+func (m codeMatcher) Matches(x interface{}) bool {
+	link, ok := x.(repository.Link)
+	return ok && link.Code == string(m)
+}
+
+func (m codeMatcher) String() string {
+	return "has short code " + string(m)
+}
+
 func (s *TSuite) TestGenerate_GetValidReqError() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
@@ -154,14 +184,12 @@ func (s *TSuite) TestGenerate_GetValidError() {
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
+	mockGenerator.EXPECT().Generate(gomock.Any(), gomock.Any(), 0).Return("abc", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(false, errors.New("redis error"))
 
 	mockReqBody := `{
-		"short_code": "test",
 		"full_url": "https://www.speedtest.net",
-		"expire_date":1619766384,
+		"expire_date":4102444800,
 		"number_of_hits": 10
 	}`
 
@@ -173,7 +201,8 @@ func (s *TSuite) TestGenerate_GetValidError() {
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
 	s.Assert().Equal(http.StatusBadRequest, w.Code)
-	s.Assert().Contains(string(body), `"code":1004`)
+	// the storage error is logged, not answered
+	s.Assert().Contains(string(body), `"code":1004,"message":"Error redis"}`)
 }
 
 func (s *TSuite) TestGenerate_URLSuccess() {
@@ -181,14 +210,13 @@ func (s *TSuite) TestGenerate_URLSuccess() {
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockGenerator.EXPECT().Generate(gomock.Any(), gomock.Any(), 0).Return("abc", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.testlongtestlongtestlongtestlongtestlongtestlong.net"), "abc", int64(4102444800)).Return(nil)
 
 	mockReqBody := `{
-		"short_code": "test",
 		"full_url": "https://www.testlongtestlongtestlongtestlongtestlongtestlong.net",
-		"expire_date":1619766384,
+		"expire_date":4102444800,
 		"number_of_hits": 10
 	}`
 
@@ -202,3 +230,462 @@ func (s *TSuite) TestGenerate_URLSuccess() {
 	s.Assert().Equal(http.StatusCreated, w.Code)
 	s.Assert().Contains(string(body), `"code":302`)
 }
+
+func (s *TSuite) TestGenerate_AliasInvalid() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	mockReqBody := `{
+		"short_code": "my alias!",
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestGenerate_AliasReserved() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	mockReqBody := `{
+		"short_code": "generate",
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestGenerate_AliasConflict() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("my-alias")).Return(false, nil)
+
+	mockReqBody := `{
+		"short_code": "my-alias",
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusConflict, w.Code)
+	s.Assert().Contains(string(body), `"code":1007`)
+}
+
+func (s *TSuite) TestGenerate_AliasSuccess() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().Equal("my-alias", link.Code)
+		s.Assert().Equal("https://www.speedtest.net", link.FullURL)
+		s.Assert().Equal(int64(4102444800), link.ExpireAt)
+		s.Assert().Equal(int64(10), link.MaxHits)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "my-alias", int64(4102444800)).Return(nil)
+
+	mockReqBody := `{
+		"short_code": "my-alias",
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_url":"http://example.com/my-alias"`)
+}
+
+func (s *TSuite) TestGenerate_URLBlacklisted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	list, err := blacklist.New(blacklist.Config{Patterns: []string{`(?i)speedtest\.net`}}, nil)
+	s.Require().NoError(err)
+	StorageService.Blacklist = list
+
+	mockReqBody := `{
+		"full_url": "https://www.SpeedTest.net/run",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1002`)
+}
+
+func (s *TSuite) TestGenerate_RetryOnCollision() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	gomock.InOrder(
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(false, nil),
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 1).Return("def", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("def")).Return(true, nil),
+		mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "def", int64(4102444800)).Return(nil),
+	)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"def"`)
+}
+
+func (s *TSuite) TestGenerate_SameURLCollides() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// the link of the same url is never overwritten with the parameters of another request
+	gomock.InOrder(
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(false, nil),
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 1).Return("def", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("def")).Return(true, nil),
+	)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "def", int64(4102444800)).Return(nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"def"`)
+}
+
+func (s *TSuite) TestGenerate_SameURLReused() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// the index lost the url, the generated code finds the link again
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net")).Return("", repository.ErrNotFound)
+	mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(false, nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, Hits: 3}, nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10,
+		"reuse_existing": true
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"abc"`)
+}
+
+func (s *TSuite) TestGenerate_SameURLReusedConflict() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), gomock.Any()).Return("", repository.ErrNotFound)
+	mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(false, nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 5}, nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10,
+		"reuse_existing": true
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusConflict, w.Code)
+	s.Assert().Contains(string(body), `"code":1012`)
+}
+
+func (s *TSuite) TestGenerate_RetriesExhausted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockGenerator.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).Return("abc", nil).Times(3)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(false, nil).Times(3)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Content-Type", "application/json")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusServiceUnavailable, w.Code)
+	s.Assert().Contains(string(body), `"code":1011`)
+}
+
+func (s *TSuite) TestGenerate_IdempotencyKeyReplayed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.IdempotencyKey("", "key-1")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+	mockIndex.EXPECT().Claim(gomock.Any(), repository.IdempotencyKey("", "key-1"), "abc", gomock.Any()).Return("abc", nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Idempotency-Key", "key-1")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"abc"`)
+}
+
+func (s *TSuite) TestGenerate_IdempotencyKeyConflict() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.IdempotencyKey("", "key-1")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 5}, nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Idempotency-Key", "key-1")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusConflict, w.Code)
+	s.Assert().Contains(string(body), `"code":1012`)
+}
+
+func (s *TSuite) TestGenerate_IdempotencyKeyTooLong() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Idempotency-Key", strings.Repeat("k", 129))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestGenerate_IdempotencyKeyLostRace() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	gomock.InOrder(
+		mockIndex.EXPECT().Lookup(gomock.Any(), repository.IdempotencyKey("", "key-1")).Return("", repository.ErrNotFound),
+		mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("abc", nil),
+		mockRepository.EXPECT().Create(gomock.Any(), hasCode("abc")).Return(true, nil),
+		mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "abc", int64(4102444800)).Return(nil),
+		mockIndex.EXPECT().Claim(gomock.Any(), repository.IdempotencyKey("", "key-1"), "abc", gomock.Any()).Return("xyz", nil),
+		mockIndex.EXPECT().Lookup(gomock.Any(), repository.IdempotencyKey("", "key-1")).Return("xyz", nil),
+		mockRepository.EXPECT().Get(gomock.Any(), "xyz").Return(&repository.Link{Code: "xyz", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil),
+		mockRepository.EXPECT().Delete(gomock.Any(), "abc").Return(nil),
+	)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	testRequest.Header.Set("Idempotency-Key", "key-1")
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"xyz"`)
+}
+
+func (s *TSuite) TestGenerate_ReuseExisting() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, Hits: 3}, nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10,
+		"reuse_existing": true
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"abc"`)
+}
+
+func (s *TSuite) TestGenerate_ReuseExistingConflict() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444000, MaxHits: 10}, nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10,
+		"reuse_existing": true
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusConflict, w.Code)
+	s.Assert().Contains(string(body), `"code":1012`)
+}
+
+func (s *TSuite) TestGenerate_ReuseExistingDeleted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockIndex.EXPECT().Lookup(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net")).Return("abc", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(nil, repository.ErrDeleted)
+	mockGenerator.EXPECT().Generate(gomock.Any(), "https://www.speedtest.net", 0).Return("def", nil)
+	mockRepository.EXPECT().Create(gomock.Any(), hasCode("def")).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "def", int64(4102444800)).Return(nil)
+
+	mockReqBody := `{
+		"full_url": "https://www.speedtest.net",
+		"expire_date":4102444800,
+		"number_of_hits": 10,
+		"reuse_existing": true
+	}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"def"`)
+}
@@ -0,0 +1,40 @@
+package generate
+
+import (
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"url-shortener/internal/http/rest"
+)
+
+// maxPasswordLen is the longest password bcrypt hashes in full
+const maxPasswordLen = 72
+
+// hashPassword keeps the bcrypt hash of the password of request, the password itself is never stored
+func hashPassword(request *ShortenerRequest) *failure {
+	if request.Password == "" {
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return &failure{
+			status: http.StatusBadRequest,
+			response: rest.Response{
+				Code:    rest.ErrCodeBadRequest["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "password"),
+			},
+			msg: fmt.Sprintf("bcrypt.GenerateFromPassword (%v)", err),
+		}
+	}
+	request.passwordHash = string(hash)
+	return nil
+}
+
+// samePassword reports whether password opens a link protected by hash, both empty for public links
+func samePassword(hash string, password string) bool {
+	if hash == "" || password == "" {
+		return hash == "" && password == ""
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGenerate_PasswordHashed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().NotEqual("secret", link.PasswordHash)
+		s.Assert().NoError(bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte("secret")))
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10, "password": "secret"}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"protected":true`)
+	s.Assert().NotContains(string(body), "secret")
+}
+
+func (s *TSuite) TestGenerate_PasswordTooLong() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	mockReqBody := `{"full_url": "https://www.speedtest.net", "number_of_hits": 10, "password": "` + strings.Repeat("p", maxPasswordLen+1) + `"}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+	s.Assert().Contains(string(body), "password")
+}
@@ -0,0 +1,63 @@
+package generate
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGenerate_UnsafeURL() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	cases := map[string]string{
+		"ftp://files.example.com/report.pdf": `"code":1019`,
+		"javascript:alert(1)":                `"code":1019`,
+		"data:text/html;base64,PHNjcmlwdD4=": `"code":1019`,
+		"http://192.168.1.1/admin":           `"code":1020`,
+		"http://localhost:3000/abc":          `"code":1020`,
+		// the host the request was sent to serves short links
+		"http://example.com/abc": `"code":1021`,
+	}
+	for fullURL, code := range cases {
+		mockReqBody := `{"full_url": "` + fullURL + `", "number_of_hits": 10}`
+
+		w := httptest.NewRecorder()
+		testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+		StorageService.GenerateUrlShortener(w, testRequest)
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code, fullURL)
+		s.Assert().Contains(string(body), code, fullURL)
+	}
+}
+
+func (s *TSuite) TestGenerate_NormalizedURL() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().Equal("https://www.speedtest.net/run", link.FullURL)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net/run"), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://WWW.SpeedTest.net:443/run#result", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"full_url":"https://www.speedtest.net/run"`)
+}
@@ -0,0 +1,32 @@
+package generate
+
+import (
+	"encoding/base64"
+	"github.com/rs/zerolog/log"
+	"url-shortener/internal/qrcode"
+)
+
+// withQR adds the QR code of its short url to response when request asks for it. The link exists
+// whether or not its QR code could be rendered, a failure only leaves the QR code out.
+func (s *StorageService) withQR(request *ShortenerRequest, response *ShortenerResponse) *ShortenerResponse {
+	if !request.QR {
+		return response
+	}
+
+	size := s.QRSize
+	if size <= 0 {
+		size = qrcode.DefaultSize
+	}
+	code, err := qrcode.Encode([]byte(response.ShortURL), qrcode.LevelM)
+	var image []byte
+	if err == nil {
+		image, err = code.PNG(size, qrcode.QuietZone)
+	}
+	if err != nil {
+		log.Warn().Msgf("render QR code of %v (%v)", response.ShortURL, err)
+		return response
+	}
+
+	response.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)
+	return response
+}
@@ -0,0 +1,60 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGenerate_QRCode() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.QRSize = 128
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10, "qr": true}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	var response struct {
+		Data ShortenerResponse `json:"data"`
+	}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Require().True(strings.HasPrefix(response.Data.QRCode, "data:image/png;base64,"))
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(response.Data.QRCode, "data:image/png;base64,"))
+	s.Require().NoError(err)
+	img, err := png.Decode(bytes.NewReader(data))
+	s.Require().NoError(err)
+	s.Assert().Equal(128, img.Bounds().Dx())
+}
+
+func (s *TSuite) TestGenerate_WithoutQRCode() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().NotContains(w.Body.String(), "qr_code")
+}
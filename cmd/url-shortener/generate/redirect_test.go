@@ -0,0 +1,53 @@
+package generate
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGenerate_RedirectStatus() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) (bool, error) {
+		s.Assert().Equal(http.StatusMovedPermanently, link.RedirectStatus)
+		return true, nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10, "redirect_status": 301}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Contains(string(body), `"redirect_status":301`)
+}
+
+func (s *TSuite) TestGenerate_RedirectStatusInvalid() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	mockReqBody := `{"full_url": "https://www.speedtest.net", "number_of_hits": 10, "redirect_status": 303}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+	s.Assert().Contains(string(body), "redirect_status")
+}
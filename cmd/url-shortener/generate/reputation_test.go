@@ -0,0 +1,116 @@
+package generate
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/reputation"
+)
+
+// stubChecker answers the verdict of the urls it holds, an empty verdict fails the check
+type stubChecker map[string]reputation.Verdict
+
+func (c stubChecker) Check(fullURL string) (reputation.Verdict, error) {
+	if verdict, ok := c[fullURL]; ok && verdict.Threat == "" {
+		return verdict, errors.New("reputation source unavailable")
+	}
+	return c[fullURL], nil
+}
+
+func (s *TSuite) TestGenerate_FlaggedURL() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Reputation = stubChecker{"http://phishing.example/login": {Threat: "SOCIAL_ENGINEERING", Confirmed: true, Action: reputation.ActionRefuse}}
+
+	mockReqBody := `{"full_url": "http://PHISHING.example/login", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1022`)
+	s.Assert().Contains(string(body), `SOCIAL_ENGINEERING`)
+}
+
+func (s *TSuite) TestGenerate_ReputationUnavailable() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// the sweeper checks the link again later
+	StorageService.Reputation = stubChecker{"https://www.speedtest.net": {}}
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+}
+
+func (s *TSuite) TestGenerate_UnconfirmedFlag() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// a hash prefix match alone does not refuse the link by default
+	StorageService.Reputation = stubChecker{"https://www.speedtest.net": {Threat: "MALWARE", Action: reputation.ActionWarn}}
+	mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(true, nil)
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.speedtest.net"), "my-alias", gomock.Any()).Return(nil)
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusCreated, w.Code)
+}
+
+func (s *TSuite) TestGenerate_PrefixRefused() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Reputation = stubChecker{"https://www.speedtest.net": {Threat: "MALWARE", Action: reputation.ActionRefuse}}
+
+	mockReqBody := `{"short_code": "my-alias", "full_url": "https://www.speedtest.net", "number_of_hits": 10}`
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(mockReqBody))
+	StorageService.GenerateUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1022`)
+}
+
+func (s *TSuite) TestUpdate_FlaggedURL() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Reputation = stubChecker{"https://www.example.com": {Threat: "MALWARE", Confirmed: true, Action: reputation.ActionRefuse}}
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"full_url": "https://www.example.com"}`, `"2"`))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1022`)
+}
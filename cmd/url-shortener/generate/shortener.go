@@ -1,14 +1,106 @@
 package generate
 
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/http/rest"
+)
+
+var (
+	aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{4,32}$`)
+
+	// reservedAliases can not be used as short_code because they collide with routes
+	reservedAliases = map[string]bool{
+		"generate": true,
+		"admin":    true,
+		"metrics":  true,
+		"healthz":  true,
+		"readyz":   true,
+	}
+
+	// redirectStatuses are the statuses a link can redirect with
+	redirectStatuses = map[int]bool{
+		http.StatusMovedPermanently:  true,
+		http.StatusFound:             true,
+		http.StatusTemporaryRedirect: true,
+		http.StatusPermanentRedirect: true,
+	}
+)
+
 type ShortenerRequest struct {
-	ShortCode    string `json:"short_code" valid:"required"`
-	FullURL      string `json:"full_url" valid:"required,url"`
-	ExpireDate   int64  `json:"expire_date" valid:"int"`
+	ShortCode  string `json:"short_code" valid:"optional,alias"`
+	FullURL    string `json:"full_url" valid:"required,url"`
+	ExpireDate int64  `json:"expire_date" valid:"optional,int"`
+	// ExpiresIn is an expiry relative to now such as 72h, it can not be combined with ExpireDate
+	ExpiresIn    string `json:"expires_in" valid:"optional"`
 	NumberOfHits int    `json:"number_of_hits" valid:"required,int"`
+	// ReuseExisting answers with the link already created for full_url instead of creating another one
+	ReuseExisting bool `json:"reuse_existing" valid:"optional"`
+	// Password protects the link, visitors have to enter it before being redirected
+	Password string `json:"password" valid:"optional"`
+	// RedirectStatus is 301, 302, 307 or 308, links redirect with the status of their domain or 302 when it is missing
+	RedirectStatus int `json:"redirect_status" valid:"optional,int"`
+	// Domain is the configured host the link is created on, the host of the request when it is missing
+	Domain string `json:"domain" valid:"optional"`
+	// QR adds the QR code of the short url to the response of POST /generate, batches ignore it
+	QR bool `json:"qr" valid:"optional"`
+
+	// relativeExpiry is set when ExpireDate was computed from ExpiresIn or the default expiry
+	relativeExpiry bool
+	passwordHash   string
+	// owner is the tenant of the caller
+	owner  string
+	domain *domain.Domain
 }
 
 type ShortenerResponse struct {
 	ShortCode string `json:"short_code"`
 	FullURL   string `json:"full_url"`
 	ShortURL  string `json:"short_url"`
+	// ExpireDate is the effective expiry, missing when the link never expires
+	ExpireDate int64 `json:"expire_date,omitempty"`
+	Protected  bool  `json:"protected,omitempty"`
+	// RedirectStatus is the status visitors are redirected with
+	RedirectStatus int `json:"redirect_status"`
+	// QRCode is the QR code of ShortURL as a base64 PNG data url, when the request asked for it
+	QRCode string `json:"qr_code,omitempty"`
+}
+
+// isValidAlias reports whether a client supplied short_code can be used as a custom alias
+func isValidAlias(alias string) bool {
+	return aliasPattern.MatchString(alias) && !reservedAliases[strings.ToLower(alias)]
+}
+
+// UpdateRequest holds the fields to change, missing fields are kept and an expire_date of 0 removes the expiry
+type UpdateRequest struct {
+	FullURL      *string `json:"full_url"`
+	ExpireDate   *int64  `json:"expire_date"`
+	NumberOfHits *int    `json:"number_of_hits"`
+}
+
+type UpdateResponse struct {
+	ShortCode    string `json:"short_code"`
+	FullURL      string `json:"full_url"`
+	ShortURL     string `json:"short_url"`
+	ExpireDate   int64  `json:"expire_date,omitempty"`
+	NumberOfHits int64  `json:"number_of_hits"`
+	Version      int64  `json:"version"`
+}
+
+// BatchResult is the outcome of one item of a batch, Index is its position in the request
+type BatchResult struct {
+	Index  int                `json:"index"`
+	Status int                `json:"status"`
+	Data   *ShortenerResponse `json:"data,omitempty"`
+	Error  *rest.Response     `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Items   []BatchResult `json:"items"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	// Existing counts the items answered with a link created before, see reuse_existing
+	Existing int `json:"existing"`
 }
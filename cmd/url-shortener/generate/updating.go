@@ -0,0 +1,203 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository"
+)
+
+// UpdateUrlShortener changes the destination, expiry or quota of a link, its short code and hits are kept.
+// The response carries the new version as ETag. Sending it back in If-Match makes the update fail with 412
+// when the link was modified since, without If-Match a concurrent update between read and write fails too.
+// The code is looked up on the domain named by the domain parameter, on the domain of the Host header without it.
+func (s *StorageService) UpdateUrlShortener(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("UpdateUrlShortener")
+
+	ctx := r.Context()
+	code := mux.Vars(r)["code"]
+	site := s.Domains.Select(r.URL.Query().Get("domain"), r.Host)
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	// step: the domain parameter names the domain of the code
+	if site == nil {
+		msg := fmt.Sprintf("Invalid parameter (domain %v is not configured)", r.URL.Query().Get("domain"))
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "domain")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		msg := fmt.Sprintf("ioutil.ReadAll (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = msg
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	request := new(UpdateRequest)
+	err = json.Unmarshal(bodyBytes, request)
+	if err == nil && request.FullURL == nil && request.ExpireDate == nil && request.NumberOfHits == nil {
+		err = fmt.Errorf("nothing to update")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("json.Unmarshal (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "body")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "If-Match")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	// step : load the link
+	link, err := s.Repository.Get(ctx, site.Code(code))
+	if err == nil && !auth.CanManage(ctx, link.Owner) {
+		msg := fmt.Sprintf("url of another tenant (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeForbidden["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeForbidden["Message"].(string), "the url belongs to another tenant")
+		rest.WriteResponse(w, http.StatusForbidden, respErr)
+		return
+	}
+	if err == nil && version > 0 && version != link.Version {
+		err = repository.ErrVersionMismatch
+	}
+	if failed := updateFailure(code, version, err); failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+
+	// step : validate the link as it will be stored
+	merged := &ShortenerRequest{
+		FullURL:      link.FullURL,
+		ExpireDate:   link.ExpireAt,
+		NumberOfHits: int(link.MaxHits),
+		domain:       site,
+	}
+	if request.FullURL != nil {
+		merged.FullURL = *request.FullURL
+	}
+	if request.ExpireDate != nil {
+		merged.ExpireDate = *request.ExpireDate
+	}
+	if request.NumberOfHits != nil {
+		merged.NumberOfHits = *request.NumberOfHits
+	}
+	if failed := s.validate(merged); failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+
+	// step : store it, only when nobody changed it since it was read
+	link.FullURL = merged.FullURL
+	link.ExpireAt = merged.ExpireDate
+	link.MaxHits = int64(merged.NumberOfHits)
+	err = s.Repository.Update(ctx, *link)
+	if failed := updateFailure(code, link.Version, err); failed != nil {
+		log.Error().Msgf(fmtError, failed.msg)
+		rest.WriteResponse(w, failed.status, &rest.ErrorResponse{Error: failed.response})
+		return
+	}
+	link.Version++
+
+	if err = s.Index.Put(ctx, repository.URLKey(link.Owner, site.Namespace, link.FullURL), link.Code, link.ExpireAt); err != nil {
+		log.Warn().Msgf("index full_url of %v (%v)", link.Code, err)
+	}
+
+	data := &UpdateResponse{
+		ShortCode:    code,
+		FullURL:      link.FullURL,
+		ShortURL:     site.ShortURL(code),
+		ExpireDate:   link.ExpireAt,
+		NumberOfHits: link.MaxHits,
+		Version:      link.Version,
+	}
+	fmt.Println("UpdateUrlShortener : Success")
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(link.Version, 10)))
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    data,
+	})
+
+	return
+}
+
+// updateFailure maps an error reading or updating code at version to the error answered, nil without error
+func updateFailure(code string, version int64, err error) *failure {
+	switch err {
+	case nil:
+		return nil
+	case repository.ErrNotFound:
+		return &failure{
+			status: http.StatusNotFound,
+			response: rest.Response{
+				Code:    rest.ErrCodeNotfound["Code"].(int),
+				Message: rest.ErrCodeNotfound["Message"].(string),
+			},
+			msg: fmt.Sprintf("url not found (%v)", code),
+		}
+	case repository.ErrDeleted:
+		return &failure{
+			status: http.StatusGone,
+			response: rest.Response{
+				Code:    rest.ErrCodeUrlDeleted["Code"].(int),
+				Message: rest.ErrCodeUrlDeleted["Message"].(string),
+			},
+			msg: fmt.Sprintf("url deleted (%v)", code),
+		}
+	case repository.ErrVersionMismatch:
+		return &failure{
+			status: http.StatusPreconditionFailed,
+			response: rest.Response{
+				Code:    rest.ErrCodeVersion["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeVersion["Message"].(string), version),
+			},
+			msg: fmt.Sprintf("url modified (%v since version %v)", code, version),
+		}
+	}
+	return storeFailure(code, true, err)
+}
+
+// parseIfMatch reads the version from an If-Match header holding one ETag, 0 when any version matches
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if len(header) < 2 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, fmt.Errorf("If-Match must hold one quoted ETag: %v", header)
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("unknown ETag: %v", header)
+	}
+	return version, nil
+}
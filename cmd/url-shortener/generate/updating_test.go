@@ -0,0 +1,175 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/repository"
+)
+
+func patchRequest(body string, ifMatch string) *http.Request {
+	request := httptest.NewRequest(http.MethodPatch, "/admin/urls/abc", strings.NewReader(body))
+	if ifMatch != "" {
+		request.Header.Set("If-Match", ifMatch)
+	}
+	return asAdmin(mux.SetURLVars(request, map[string]string{"code": "abc"}))
+}
+
+// asAdmin authenticates r with the admin token
+func asAdmin(r *http.Request) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), auth.Admin))
+}
+
+func storedLink() *repository.Link {
+	return &repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, Hits: 3, Version: 2}
+}
+
+func (s *TSuite) TestUpdate_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil)
+	mockRepository.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) error {
+		s.Assert().Equal("https://www.example.com", link.FullURL)
+		s.Assert().Equal(int64(4102444800), link.ExpireAt)
+		s.Assert().Equal(int64(10), link.MaxHits)
+		s.Assert().Equal(int64(2), link.Version)
+		return nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), repository.URLKey("", "", "https://www.example.com"), "abc", int64(4102444800)).Return(nil)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"full_url": "https://www.example.com"}`, `"2"`))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Equal(`"3"`, resp.Header.Get("ETag"))
+	s.Assert().Contains(string(body), `"short_code":"abc","full_url":"https://www.example.com"`)
+	s.Assert().Contains(string(body), `"version":3`)
+}
+
+func (s *TSuite) TestUpdate_RemoveExpiry() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil)
+	mockRepository.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, link repository.Link) error {
+		s.Assert().Equal(int64(0), link.ExpireAt)
+		s.Assert().Equal(int64(50), link.MaxHits)
+		return nil
+	})
+	mockIndex.EXPECT().Put(gomock.Any(), gomock.Any(), "abc", int64(0)).Return(nil)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"expire_date": 0, "number_of_hits": 50}`, ""))
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+}
+
+func (s *TSuite) TestUpdate_Invalid() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil).Times(2)
+
+	for body, code := range map[string]string{
+		`{}`:                          `"code":1001`,
+		`{"full_url": "test"}`:        `"code":1001`,
+		`{"expire_date": 1619766384}`: `"code":1003`,
+	} {
+		w := httptest.NewRecorder()
+		StorageService.UpdateUrlShortener(w, patchRequest(body, ""))
+
+		resp := w.Result()
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code, body)
+		s.Assert().Contains(string(respBody), code, body)
+	}
+}
+
+func (s *TSuite) TestUpdate_InvalidIfMatch() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"number_of_hits": 50}`, `W/"2"`))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestUpdate_StaleIfMatch() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"number_of_hits": 50}`, `"1"`))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusPreconditionFailed, w.Code)
+	s.Assert().Contains(string(body), `"code":1013`)
+}
+
+func (s *TSuite) TestUpdate_ConcurrentUpdate() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(storedLink(), nil)
+	mockRepository.EXPECT().Update(gomock.Any(), gomock.Any()).Return(repository.ErrVersionMismatch)
+
+	w := httptest.NewRecorder()
+	StorageService.UpdateUrlShortener(w, patchRequest(`{"number_of_hits": 50}`, ""))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusPreconditionFailed, w.Code)
+	s.Assert().Contains(string(body), `"code":1013`)
+}
+
+func (s *TSuite) TestUpdate_NotFoundOrDeleted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	gomock.InOrder(
+		mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(nil, repository.ErrNotFound),
+		mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(nil, repository.ErrDeleted),
+		mockRepository.EXPECT().Get(gomock.Any(), "abc").Return(nil, errors.New("redis error")),
+	)
+
+	for _, want := range []struct {
+		status int
+		code   string
+	}{
+		{http.StatusNotFound, `"code":1006`},
+		{http.StatusGone, `"code":1010`},
+		{http.StatusBadRequest, `"code":1004`},
+	} {
+		w := httptest.NewRecorder()
+		StorageService.UpdateUrlShortener(w, patchRequest(`{"number_of_hits": 50}`, ""))
+
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		s.Assert().Equal(want.status, w.Code)
+		s.Assert().Contains(string(body), want.code)
+	}
+}
@@ -1,22 +1,31 @@
 package getting
 
 import (
+	"context"
 	"fmt"
 	"github.com/asaskevich/govalidator"
 	"github.com/gorilla/mux"
-	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rs/zerolog/log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/domain"
 	"url-shortener/internal/http/rest"
-	"url-shortener/internal/repository/redis"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/cache"
 )
 
 const fmtError = "%v"
 
 type Service interface {
 	GetUrlShortener(w http.ResponseWriter, r *http.Request)
+	PreviewUrlShortener(w http.ResponseWriter, r *http.Request)
+	QRUrlShortener(w http.ResponseWriter, r *http.Request)
 }
 
 func init() {
@@ -24,74 +33,172 @@ func init() {
 }
 
 type StorageService struct {
-	RedisHandler redis.HandlerInterface
-	RedisConfig  redis.Config
+	Repository repository.LinkRepository
+	Blacklist  *blacklist.Blacklist
+	Recorder   *analytics.Recorder
+	// Attempts limits wrong passwords per short code and client, as told by Client
+	Attempts *ratelimit.Limiter
+	Client   func(r *http.Request) string
+	Domains  *domain.Domains
+	QR       QRPolicy
+	// QRCodes caches the rendered QR codes
+	QRCodes *cache.Images
 }
 
-func NewService(redisHandler *redis.Handler, redisConfig redis.Config) Service {
+func NewService(links repository.LinkRepository, list *blacklist.Blacklist, recorder *analytics.Recorder, attempts *ratelimit.Limiter, client func(r *http.Request) string, domains *domain.Domains, qr QRPolicy, qrCodes *cache.Images) Service {
 	return &StorageService{
-		RedisHandler: redisHandler,
-		RedisConfig:  redisConfig,
+		Repository: links,
+		Blacklist:  list,
+		Recorder:   recorder,
+		Attempts:   attempts,
+		Client:     client,
+		Domains:    domains,
+		QR:         qr,
+		QRCodes:    qrCodes,
 	}
 }
 
+// GetUrlShortener redirects to the destination of a link with its redirect status, ?preview=1 shows
+// the link instead. A protected link first asks for its password, sent in the X-Link-Password header
+// or posted from the unlock form served to browsers. Codes are looked up on the domain of the Host header.
 func (s *StorageService) GetUrlShortener(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("preview") == "1" {
+		s.PreviewUrlShortener(w, r)
+		return
+	}
 	fmt.Println("GetUrlShortener")
 
 	ctx := r.Context()
-	txn := newrelic.FromContext(ctx)
-	code := mux.Vars(r)["code"]
+	code := s.Domains.ForHost(r.Host).Code(mux.Vars(r)["code"])
 
 	respErr := &rest.ErrorResponse{
 		Error: rest.Response{},
 	}
-	// step: check data from redis
-	// check exp
-	exp := fmt.Sprintf("%v%v:%v", s.RedisConfig.Key, code, "expire")
-	expireTime, err := s.RedisHandler.Get(exp, txn)
-	if err != nil {
-		msg := fmt.Sprintf("redis error (%v)", err)
-		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
-		respErr.Error.Message = msg
-		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+	link, ok := s.resolve(ctx, w, r, code)
+	if !ok {
 		return
 	}
 
-	times, _ := strconv.ParseInt(expireTime, 0, 64)
-	if expireTime != "" && times <= time.Now().Unix() {
-		msg := fmt.Sprintf("redis error (%v)", err)
+	// step: count the visit, the repository enforces the hit quota so rejected visits are not counted
+	_, err := s.Repository.IncrementHits(ctx, code)
+	if err == repository.ErrNotFound {
+		// the link was reclaimed after it was read
+		s.notFound(w, code)
+		return
+	} else if err == repository.ErrHitsExceeded {
+		msg := fmt.Sprintf("hit quota exhausted (%v hits)", link.MaxHits)
 		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeUrlExp["Code"].(int)
-		respErr.Error.Message = rest.ErrCodeUrlExp["Message"].(string)
+		respErr.Error.Code = rest.ErrCodeHitsExceeded["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeHitsExceeded["Message"].(string)
 		rest.WriteResponse(w, http.StatusGone, respErr)
 		return
-	}
-
-	// check found
-	url := fmt.Sprintf("%v%v:%v", s.RedisConfig.Key, code, "full")
-	urlRes, err := s.RedisHandler.Get(url, txn)
-	if err != nil {
-		msg := fmt.Sprintf("redis error (%v)", err)
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
 		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
-		respErr.Error.Message = msg
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
 		rest.WriteResponse(w, http.StatusBadRequest, respErr)
 		return
 	}
 
-	if urlRes == "" {
-		msg := fmt.Sprintf("url not found (%v)", err)
-		log.Error().Msgf(fmtError, msg)
-		respErr.Error.Code = rest.ErrCodeNotfound["Code"].(int)
-		respErr.Error.Message = rest.ErrCodeNotfound["Message"].(string)
-		rest.WriteResponse(w, http.StatusNotFound, respErr)
-		return
-	}
+	// step: count the click in the background
+	s.Recorder.Record(r, code)
 
 	fmt.Println("GetUrlShortener : Success")
-	// Redirect
-	http.Redirect(w, r, urlRes, 302)
+	metrics.Redirects.WithLabelValues(strconv.Itoa(link.StatusCode())).Inc()
+	// Redirect, browsers cache 301 and 308 so their later visits are neither counted nor checked
+	http.Redirect(w, r, link.FullURL, link.StatusCode())
 
 	return
 }
+
+// resolve loads the link of the stored code and checks it can be followed, it answers the request and returns false otherwise
+func (s *StorageService) resolve(ctx context.Context, w http.ResponseWriter, r *http.Request, code string) (*repository.Link, bool) {
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+	link, ok := s.load(ctx, w, code)
+	if !ok {
+		return nil, false
+	}
+
+	// links created before their destination was blacklisted stop resolving
+	if pattern, matched := s.Blacklist.Match(link.FullURL); matched {
+		msg := fmt.Sprintf("blacklisted url (%v matches %v)", link.FullURL, pattern)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeURLInvalid["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeURLInvalid["Message"].(string), link.FullURL)
+		rest.WriteResponse(w, http.StatusForbidden, respErr)
+		return nil, false
+	}
+
+	// links flagged by the reputation sweeper warn instead of redirecting
+	if link.Flagged != "" {
+		msg := fmt.Sprintf("flagged url (%v reported as %v)", code, link.Flagged)
+		log.Error().Msgf(fmtError, msg)
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			respErr.Error.Code = rest.ErrCodeURLFlagged["Code"].(int)
+			respErr.Error.Message = fmt.Sprintf(rest.ErrCodeURLFlagged["Message"].(string), link.Flagged)
+			rest.WriteResponse(w, http.StatusForbidden, respErr)
+		} else {
+			writeWarningPage(w, link.Flagged)
+		}
+		return nil, false
+	}
+
+	// step: protected links are only opened with their password
+	if link.PasswordHash != "" && !s.unlock(w, r, code, link.PasswordHash) {
+		return nil, false
+	}
+	return link, true
+}
+
+// load reads the link of the stored code and checks it has neither been deleted nor expired,
+// it answers the request and returns false otherwise
+func (s *StorageService) load(ctx context.Context, w http.ResponseWriter, code string) (*repository.Link, bool) {
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+	// step: load the link
+	link, err := s.Repository.Get(ctx, code)
+	if err == repository.ErrNotFound {
+		s.notFound(w, code)
+		return nil, false
+	} else if err == repository.ErrDeleted {
+		msg := fmt.Sprintf("url deleted (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeUrlDeleted["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeUrlDeleted["Message"].(string)
+		rest.WriteResponse(w, http.StatusGone, respErr)
+		return nil, false
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return nil, false
+	}
+
+	// check exp
+	if link.ExpireAt > 0 && link.ExpireAt <= time.Now().Unix() {
+		msg := fmt.Sprintf("url expired (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeUrlExp["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeUrlExp["Message"].(string)
+		rest.WriteResponse(w, http.StatusGone, respErr)
+		return nil, false
+	}
+	return link, true
+}
+
+func (s *StorageService) notFound(w http.ResponseWriter, code string) {
+	msg := fmt.Sprintf("url not found (%v)", code)
+	log.Error().Msgf(fmtError, msg)
+	rest.WriteResponse(w, http.StatusNotFound, &rest.ErrorResponse{
+		Error: rest.Response{
+			Code:    rest.ErrCodeNotfound["Code"].(int),
+			Message: rest.ErrCodeNotfound["Message"].(string),
+		},
+	})
+}
@@ -1,14 +1,21 @@
 package getting
 
 import (
+	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/suite"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	mockredis "url-shortener/internal/repository/redis/mocks"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/http/middleware"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
 )
 
 type TSuite struct {
@@ -20,23 +27,24 @@ func TestSuite(t *testing.T) {
 }
 
 var (
-	mockRedis *mockredis.MockHandlerInterface
+	mockRepository *mockrepository.MockLinkRepository
 )
 
 func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
-	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
 
 	return StorageService{
-		RedisHandler: mockRedis,
+		Repository: mockRepository,
+		Client:     middleware.ClientIP(nil),
 	}
 }
 
-func (s *TSuite) TestGet_URLExpireRedisError() {
+func (s *TSuite) TestGet_URLStorageError() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", errors.New("redis error"))
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, errors.New("redis error"))
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
@@ -45,15 +53,17 @@ func (s *TSuite) TestGet_URLExpireRedisError() {
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
 	s.Assert().Equal(http.StatusBadRequest, w.Code)
-	s.Assert().Contains(string(body), `"code":1001`)
+	s.Assert().Contains(string(body), `"code":1004`)
+	// the storage error is only logged
+	s.Assert().NotContains(string(body), "redis error")
 }
 
-func (s *TSuite) TestGet_URLExpireRedis() {
+func (s *TSuite) TestGet_URLExpired() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("0", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{FullURL: "https://www.speedtest.net", ExpireAt: 1619766384}, nil)
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
@@ -65,12 +75,95 @@ func (s *TSuite) TestGet_URLExpireRedis() {
 	s.Assert().Contains(string(body), `"code":1005`)
 }
 
-func (s *TSuite) TestGet_URLGetFullErrorRedis() {
+func (s *TSuite) TestGet_URLNotFound() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", errors.New("redis error"))
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, repository.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
+
+func (s *TSuite) TestGet_URLDeleted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrDeleted)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusGone, w.Code)
+	s.Assert().Contains(string(body), `"code":1010`)
+}
+
+func (s *TSuite) TestGet_URLBlacklisted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	list, err := blacklist.New(blacklist.Config{Patterns: []string{`speedtest\.net`}}, nil)
+	s.Require().NoError(err)
+	StorageService.Blacklist = list
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(string(body), `"code":1002`)
+}
+
+func (s *TSuite) TestGet_URLFlagged() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{FullURL: "http://phishing.example", ExpireAt: 4102444800, Flagged: "SOCIAL_ENGINEERING"}, nil).Times(2)
+
+	// browsers get the warning page, the destination is left out
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(w.Header().Get("Content-Type"), "text/html")
+	s.Assert().Contains(string(body), "SOCIAL_ENGINEERING")
+	s.Assert().NotContains(string(body), "phishing.example")
+
+	w = httptest.NewRecorder()
+	testRequest = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	testRequest.Header.Set("Accept", "application/json")
+	StorageService.GetUrlShortener(w, testRequest)
+
+	body, _ = ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(string(body), `"code":1022`)
+}
+
+func (s *TSuite) TestGet_URLIncrementError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("redis error"))
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
@@ -79,16 +172,17 @@ func (s *TSuite) TestGet_URLGetFullErrorRedis() {
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
 	s.Assert().Equal(http.StatusBadRequest, w.Code)
-	s.Assert().Contains(string(body), `"code":1001`)
+	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
 }
 
-func (s *TSuite) TestGet_URLGetFullNilRedis() {
+func (s *TSuite) TestGet_URLReclaimedAfterRead() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
 	StorageService := setUpServiceMocking(ctrl)
-	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("1619766384", nil)
-	mockRedis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), gomock.Any()).Return(int64(0), repository.ErrNotFound)
 
 	w := httptest.NewRecorder()
 	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
@@ -99,3 +193,88 @@ func (s *TSuite) TestGet_URLGetFullNilRedis() {
 	s.Assert().Equal(http.StatusNotFound, w.Code)
 	s.Assert().Contains(string(body), `"code":1006`)
 }
+
+func (s *TSuite) TestGet_URLHitsExceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), gomock.Any()).Return(int64(0), repository.ErrHitsExceeded)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/code", nil)
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusGone, w.Code)
+	s.Assert().Contains(string(body), `"code":1008`)
+}
+
+func (s *TSuite) TestGet_URLSuccess() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(10), nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusFound, w.Code)
+	s.Assert().Equal("https://www.speedtest.net", w.Header().Get("Location"))
+}
+
+func (s *TSuite) TestGet_URLRecordsClick() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockStats := mockrepository.NewMockStatsRepository(ctrl)
+	StorageService.Recorder = analytics.NewRecorder(mockStats, nil, analytics.Config{})
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net"}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+	mockStats.EXPECT().RecordClick(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, click repository.Click) error {
+		s.Assert().Equal("code", click.Code)
+		s.Assert().Equal("news.example.com", click.Referrer)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	testRequest.Header.Set("Referer", "https://news.example.com/")
+	StorageService.GetUrlShortener(w, testRequest)
+	s.Assert().Equal(http.StatusFound, w.Code)
+
+	// drain the queued click
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	StorageService.Recorder.Run(ctx)
+}
+
+func (s *TSuite) TestGet_CodeOnHostDomain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	domains, err := domain.New([]domain.Config{{Host: "go.example.com", Namespace: "go"}})
+	s.Require().NoError(err)
+	StorageService.Domains = domains
+	mockRepository.EXPECT().Get(gomock.Any(), "go/code").Return(&repository.Link{Code: "go/code", FullURL: "https://www.speedtest.net"}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "go/code").Return(int64(1), nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "http://go.example.com/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+	s.Assert().Equal(http.StatusFound, w.Code)
+
+	// the same code on another host is another link
+	w = httptest.NewRecorder()
+	testRequest = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "http://localhost:3000/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+}
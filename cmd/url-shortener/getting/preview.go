@@ -0,0 +1,88 @@
+package getting
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/internal/http/rest"
+)
+
+// PreviewResponse describes a link without following it
+type PreviewResponse struct {
+	ShortCode      string `json:"short_code"`
+	FullURL        string `json:"full_url"`
+	CreatedAt      int64  `json:"created_at,omitempty"`
+	ExpireDate     int64  `json:"expire_date,omitempty"`
+	NumberOfHits   int64  `json:"number_of_hits"`
+	Hits           int64  `json:"hits"`
+	RedirectStatus int    `json:"redirect_status"`
+}
+
+var previewPage = template.Must(template.New("preview").Funcs(template.FuncMap{
+	"date": func(unix int64) string {
+		return time.Unix(unix, 0).UTC().Format(time.RFC1123)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Preview of {{.ShortCode}}</title>
+</head>
+<body>
+<p>This short link leads to:</p>
+<p><a href="{{.FullURL}}" rel="nofollow noopener noreferrer">{{.FullURL}}</a></p>
+<ul>
+<li>Created: {{if .CreatedAt}}{{date .CreatedAt}}{{else}}unknown{{end}}</li>
+<li>Expires: {{if .ExpireDate}}{{date .ExpireDate}}{{else}}never{{end}}</li>
+<li>Visits: {{.Hits}}{{if .NumberOfHits}} of {{.NumberOfHits}}{{end}}</li>
+</ul>
+</body>
+</html>
+`))
+
+// PreviewUrlShortener shows where a link leads, when it was created and how often it was followed
+// without redirecting nor counting a visit. Clients accepting application/json get the JSON
+// response, browsers an HTML page. Protected links still ask for their password.
+func (s *StorageService) PreviewUrlShortener(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("PreviewUrlShortener")
+
+	ctx := r.Context()
+	code := mux.Vars(r)["code"]
+
+	link, ok := s.resolve(ctx, w, r, s.Domains.ForHost(r.Host).Code(code))
+	if !ok {
+		return
+	}
+
+	preview := &PreviewResponse{
+		ShortCode:      code,
+		FullURL:        link.FullURL,
+		CreatedAt:      link.CreatedAt,
+		ExpireDate:     link.ExpireAt,
+		NumberOfHits:   link.MaxHits,
+		Hits:           link.Hits,
+		RedirectStatus: link.StatusCode(),
+	}
+
+	fmt.Println("PreviewUrlShortener : Success")
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Cache-Control", "no-store")
+		_ = rest.WriteResponse(w, 200, &rest.Response{
+			Code:    200,
+			Message: "Success",
+			Data:    preview,
+		})
+		return
+	}
+
+	writeHTMLHeader(w, http.StatusOK)
+	if err := previewPage.Execute(w, preview); err != nil {
+		log.Error().Msgf("preview page (%v)", err)
+	}
+	return
+}
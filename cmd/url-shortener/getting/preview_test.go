@@ -0,0 +1,86 @@
+package getting
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestGet_RedirectStatus() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net", RedirectStatus: http.StatusPermanentRedirect}, nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusPermanentRedirect, w.Code)
+	s.Assert().Equal("https://www.speedtest.net", w.Header().Get("Location"))
+}
+
+func (s *TSuite) TestPreview_JSON() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	// a preview neither counts a visit nor redirects
+	mockRepository.EXPECT().Get(gomock.Any(), "code").
+		Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net", CreatedAt: 1619766384, MaxHits: 10, Hits: 3}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code+", nil), map[string]string{"code": "code"})
+	testRequest.Header.Set("Accept", "application/json")
+	StorageService.PreviewUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Empty(w.Header().Get("Location"))
+	s.Assert().Contains(string(body), `"data":{"short_code":"code","full_url":"https://www.speedtest.net","created_at":1619766384,"number_of_hits":10,"hits":3,"redirect_status":302}`)
+}
+
+func (s *TSuite) TestPreview_QueryHTML() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").
+		Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net/?a=1&b=<2>", CreatedAt: 1619766384, Hits: 3}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code?preview=1", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Equal("text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	s.Assert().Contains(string(body), "Fri, 30 Apr 2021 07:06:24 UTC")
+	s.Assert().Contains(string(body), "Visits: 3")
+	// the destination is escaped
+	s.Assert().Contains(string(body), "https://www.speedtest.net/?a=1&amp;b=&lt;2&gt;")
+}
+
+func (s *TSuite) TestPreview_Protected() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(s.protected(), nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code+", nil), map[string]string{"code": "code"})
+	StorageService.PreviewUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusUnauthorized, w.Code)
+	s.Assert().NotContains(string(body), "speedtest")
+}
@@ -0,0 +1,145 @@
+package getting
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/qrcode"
+)
+
+const (
+	formatPNG = "png"
+	formatSVG = "svg"
+
+	maxQRMargin = 16
+)
+
+var qrContentTypes = map[string]string{
+	formatPNG: "image/png",
+	formatSVG: "image/svg+xml",
+}
+
+// QRPolicy bounds the QR codes of links. A zero Size renders images of qrcode.DefaultSize pixels,
+// a zero MaxSize allows any size.
+type QRPolicy struct {
+	Size    int
+	MaxSize int
+}
+
+// qrOptions are the parameters of a QR code image
+type qrOptions struct {
+	format string
+	size   int
+	margin int
+	level  qrcode.Level
+}
+
+// QRUrlShortener serves the QR code of the short url of a link, as PNG or as SVG with ?format=svg.
+// ?size is the width of the image in pixels, ?margin the quiet zone in modules and ?level the error
+// correction level, L, M, Q or H. Images are cached per code and parameters.
+func (s *StorageService) QRUrlShortener(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("QRUrlShortener")
+
+	ctx := r.Context()
+	site := s.Domains.ForHost(r.Host)
+	code := mux.Vars(r)["code"]
+
+	options, field := s.qrOptions(r.URL.Query())
+	if field != "" {
+		s.invalidQR(w, field, fmt.Sprintf("Invalid parameter (%v)", field))
+		return
+	}
+
+	// step: only links that can still be followed get a QR code
+	if _, ok := s.load(ctx, w, site.Code(code)); !ok {
+		return
+	}
+
+	shortURL := site.ShortURL(code)
+	key := fmt.Sprintf("%v|%v|%v|%v|%v", shortURL, options.format, options.size, options.margin, options.level)
+	image, ok := s.QRCodes.Get(key)
+	if !ok {
+		var err error
+		image, err = renderQR(shortURL, options)
+		if err != nil {
+			s.invalidQR(w, "size", fmt.Sprintf("render QR code (%v)", err))
+			return
+		}
+		s.QRCodes.Add(key, image)
+	}
+
+	fmt.Println("QRUrlShortener : Success")
+	// the short url of a code never changes
+	w.Header().Set("Content-Type", qrContentTypes[options.format])
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(image)
+}
+
+// qrOptions reads the parameters of the image from query, it returns the name of the first invalid one
+func (s *StorageService) qrOptions(query url.Values) (qrOptions, string) {
+	options := qrOptions{
+		format: formatPNG,
+		size:   s.QR.Size,
+		margin: qrcode.QuietZone,
+		level:  qrcode.LevelM,
+	}
+	if options.size <= 0 {
+		options.size = qrcode.DefaultSize
+	}
+
+	if format := query.Get("format"); format != "" {
+		if qrContentTypes[format] == "" {
+			return options, "format"
+		}
+		options.format = format
+	}
+	if value := query.Get("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 || (s.QR.MaxSize > 0 && size > s.QR.MaxSize) {
+			return options, "size"
+		}
+		options.size = size
+	}
+	if value := query.Get("margin"); value != "" {
+		margin, err := strconv.Atoi(value)
+		if err != nil || margin < 0 || margin > maxQRMargin {
+			return options, "margin"
+		}
+		options.margin = margin
+	}
+	if value := query.Get("level"); value != "" {
+		level, err := qrcode.ParseLevel(value)
+		if err != nil {
+			return options, "level"
+		}
+		options.level = level
+	}
+	return options, ""
+}
+
+func (s *StorageService) invalidQR(w http.ResponseWriter, field string, msg string) {
+	log.Error().Msgf(fmtError, msg)
+	rest.WriteResponse(w, http.StatusBadRequest, &rest.ErrorResponse{
+		Error: rest.Response{
+			Code:    rest.ErrCodeBadRequest["Code"].(int),
+			Message: fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), field),
+		},
+	})
+}
+
+// renderQR renders the QR code of shortURL, PNG images must hold at least a pixel per module
+func renderQR(shortURL string, options qrOptions) ([]byte, error) {
+	code, err := qrcode.Encode([]byte(shortURL), options.level)
+	if err != nil {
+		return nil, err
+	}
+	if options.format == formatSVG {
+		return code.SVG(options.size, options.margin), nil
+	}
+	return code.PNG(options.size, options.margin)
+}
@@ -0,0 +1,106 @@
+package getting
+
+import (
+	"bytes"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/cache"
+)
+
+func qrRequest(target string) *http.Request {
+	return mux.SetURLVars(httptest.NewRequest(http.MethodGet, target, nil), map[string]string{"code": "code"})
+}
+
+func (s *TSuite) TestQR_PNG() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.QRCodes = cache.NewImages(cache.Config{Size: 10})
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net"}, nil).Times(2)
+
+	w := httptest.NewRecorder()
+	StorageService.QRUrlShortener(w, qrRequest("/code/qr?size=300&margin=2&level=h"))
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Equal("image/png", w.Header().Get("Content-Type"))
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	s.Require().NoError(err)
+	s.Assert().Equal(300, img.Bounds().Dx())
+
+	// the second request is served the cached image
+	_, cached := StorageService.QRCodes.Get("http://example.com/code|png|300|2|H")
+	s.Assert().True(cached)
+	first := w.Body.Bytes()
+	w = httptest.NewRecorder()
+	StorageService.QRUrlShortener(w, qrRequest("/code/qr?size=300&margin=2&level=H"))
+	s.Assert().Equal(first, w.Body.Bytes())
+}
+
+func (s *TSuite) TestQR_SVG() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net"}, nil)
+
+	w := httptest.NewRecorder()
+	StorageService.QRUrlShortener(w, qrRequest("/code/qr?format=svg"))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Equal("image/svg+xml", w.Header().Get("Content-Type"))
+	s.Assert().True(strings.Contains(string(body), `width="256" height="256"`))
+}
+
+func (s *TSuite) TestQR_InvalidParameters() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.QR = QRPolicy{MaxSize: 1024}
+
+	cases := map[string]string{
+		"/code/qr?format=gif": "format",
+		"/code/qr?size=abc":   "size",
+		"/code/qr?size=2048":  "size",
+		"/code/qr?margin=-1":  "margin",
+		"/code/qr?level=X":    "level",
+	}
+	for target, field := range cases {
+		w := httptest.NewRecorder()
+		StorageService.QRUrlShortener(w, qrRequest(target))
+
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		s.Assert().Equal(http.StatusBadRequest, w.Code, target)
+		s.Assert().Contains(string(body), `"code":1001`, target)
+		s.Assert().Contains(string(body), field, target)
+	}
+
+	// too few pixels for the modules of the symbol
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", FullURL: "https://www.speedtest.net"}, nil)
+	w := httptest.NewRecorder()
+	StorageService.QRUrlShortener(w, qrRequest("/code/qr?size=20"))
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *TSuite) TestQR_NotFound() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	StorageService.QRUrlShortener(w, qrRequest("/code/qr"))
+
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
@@ -0,0 +1,99 @@
+package getting
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"url-shortener/internal/http/rest"
+)
+
+const (
+	passwordHeader = "X-Link-Password"
+	passwordField  = "password"
+)
+
+var unlockForm = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Protected link</title>
+</head>
+<body>
+<form method="post">
+<p>This link is protected by a password.</p>
+{{if .}}<p>Wrong password, please try again.</p>{{end}}
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+</body>
+</html>
+`))
+
+// unlock reports whether the request holds the password of a protected link and answers it otherwise.
+// Wrong passwords are counted per code and client, once Attempts blocks a client every password it sends
+// for the code is refused. Other clients are still let in, a client guessing can not lock the link for all.
+func (s *StorageService) unlock(w http.ResponseWriter, r *http.Request, code string, hash string) bool {
+	password, fromForm := r.Header.Get(passwordHeader), false
+	if password == "" && r.Method == http.MethodPost {
+		password, fromForm = r.PostFormValue(passwordField), true
+	}
+
+	if password == "" {
+		writeUnlockForm(w, false)
+		return false
+	}
+
+	attempts := "unlock:" + code + ":" + s.Client(r)
+	if blocked, retry := s.Attempts.Blocked(r.Context(), attempts); blocked {
+		seconds := int(math.Ceil(retry.Seconds()))
+		msg := fmt.Sprintf("too many wrong passwords (%v)", attempts)
+		log.Error().Msgf(fmtError, msg)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		rest.WriteResponse(w, http.StatusTooManyRequests, &rest.ErrorResponse{
+			Error: rest.Response{
+				Code:    rest.ErrCodeTooManyAttempts["Code"].(int),
+				Message: fmt.Sprintf(rest.ErrCodeTooManyAttempts["Message"].(string), seconds),
+			},
+		})
+		return false
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		s.Attempts.Hit(r.Context(), attempts)
+		msg := fmt.Sprintf("wrong password (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		if fromForm {
+			writeUnlockForm(w, true)
+			return false
+		}
+		rest.WriteResponse(w, http.StatusUnauthorized, &rest.ErrorResponse{
+			Error: rest.Response{
+				Code:    rest.ErrCodePassword["Code"].(int),
+				Message: rest.ErrCodePassword["Message"].(string),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func writeUnlockForm(w http.ResponseWriter, wrong bool) {
+	writeHTMLHeader(w, http.StatusUnauthorized)
+	if err := unlockForm.Execute(w, wrong); err != nil {
+		log.Error().Msgf("unlock form (%v)", err)
+	}
+}
+
+// writeHTMLHeader writes the headers of the pages served to browsers, they are never cached nor framed
+func writeHTMLHeader(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+}
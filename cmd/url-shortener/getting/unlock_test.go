@@ -0,0 +1,115 @@
+package getting
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) protected() *repository.Link {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	s.Require().NoError(err)
+	return &repository.Link{Code: "code", FullURL: "https://www.speedtest.net", PasswordHash: string(hash)}
+}
+
+func (s *TSuite) TestGet_ProtectedServesForm() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(s.protected(), nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusUnauthorized, w.Code)
+	s.Assert().Equal("no-store", w.Header().Get("Cache-Control"))
+	s.Assert().Contains(string(body), `<form method="post">`)
+	s.Assert().NotContains(string(body), "speedtest")
+}
+
+func (s *TSuite) TestGet_ProtectedUnlockHeader() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(s.protected(), nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+	testRequest.Header.Set("X-Link-Password", "secret")
+	StorageService.GetUrlShortener(w, testRequest)
+
+	s.Assert().Equal(http.StatusFound, w.Code)
+	s.Assert().Equal("https://www.speedtest.net", w.Header().Get("Location"))
+}
+
+func (s *TSuite) TestGet_ProtectedUnlockForm() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(s.protected(), nil).Times(2)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+
+	post := func(password string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		form := url.Values{"password": {password}}.Encode()
+		testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/code", strings.NewReader(form)), map[string]string{"code": "code"})
+		testRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		StorageService.GetUrlShortener(w, testRequest)
+		return w
+	}
+
+	w := post("wrong")
+	s.Assert().Equal(http.StatusUnauthorized, w.Code)
+	s.Assert().Contains(w.Body.String(), "Wrong password")
+
+	w = post("secret")
+	s.Assert().Equal(http.StatusFound, w.Code)
+}
+
+func (s *TSuite) TestGet_ProtectedWrongPassword() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	StorageService.Attempts = ratelimit.NewLimiter(nil, ratelimit.Config{Limit: 1, Window: time.Minute})
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(s.protected(), nil).Times(3)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+
+	get := func(password string, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/code", nil), map[string]string{"code": "code"})
+		testRequest.RemoteAddr = remoteAddr
+		testRequest.Header.Set("X-Link-Password", password)
+		StorageService.GetUrlShortener(w, testRequest)
+		return w
+	}
+
+	w := get("wrong", "198.51.100.9:1234")
+	s.Assert().Equal(http.StatusUnauthorized, w.Code)
+	s.Assert().Contains(w.Body.String(), `"code":1015`)
+
+	// the right password is refused too once the client is blocked
+	w = get("secret", "198.51.100.9:1234")
+	s.Assert().Equal(http.StatusTooManyRequests, w.Code)
+	s.Assert().Contains(w.Body.String(), `"code":1016`)
+	s.Assert().NotEmpty(w.Header().Get("Retry-After"))
+
+	// other clients are still let in
+	w = get("secret", "203.0.113.7:1234")
+	s.Assert().Equal(http.StatusFound, w.Code)
+}
@@ -0,0 +1,29 @@
+package getting
+
+import (
+	"github.com/rs/zerolog/log"
+	"html/template"
+	"net/http"
+)
+
+var warningPage = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link disabled</title>
+</head>
+<body>
+<h1>This link has been disabled</h1>
+<p>Its destination was reported as unsafe ({{.}}) and may try to steal your information or harm your device.</p>
+</body>
+</html>
+`))
+
+// writeWarningPage tells visitors of a flagged link why they are not redirected, the destination is left out
+func writeWarningPage(w http.ResponseWriter, threat string) {
+	writeHTMLHeader(w, http.StatusForbidden)
+	if err := warningPage.Execute(w, threat); err != nil {
+		log.Error().Msgf("warning page (%v)", err)
+	}
+}
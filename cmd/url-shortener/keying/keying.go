@@ -0,0 +1,198 @@
+package keying
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/asaskevich/govalidator"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository"
+)
+
+const fmtError = "%v"
+
+// tenants end up in storage keys, they are kept to a safe alphabet
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+type Service interface {
+	CreateKey(w http.ResponseWriter, r *http.Request)
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	DeleteKey(w http.ResponseWriter, r *http.Request)
+}
+
+func init() {
+	govalidator.SetFieldsRequiredByDefault(true)
+}
+
+type StorageService struct {
+	Repository repository.KeyRepository
+	now        func() time.Time
+}
+
+func NewService(keys repository.KeyRepository) Service {
+	return &StorageService{
+		Repository: keys,
+		now:        time.Now,
+	}
+}
+
+// CreateKey creates an API key of a tenant, the response holds the only copy of its token
+func (s *StorageService) CreateKey(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("CreateKey")
+
+	ctx := r.Context()
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		msg := fmt.Sprintf("ioutil.ReadAll (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = msg
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	// step: validate tenant and scopes
+	request := new(KeyRequest)
+	if err = json.Unmarshal(bodyBytes, request); err == nil {
+		_, err = govalidator.ValidateStruct(request)
+	}
+	if err == nil && !tenantPattern.MatchString(request.Tenant) {
+		err = fmt.Errorf("tenant must match %v", tenantPattern)
+	} else if err == nil && len(request.Scopes) == 0 {
+		err = fmt.Errorf("a key needs at least one scope")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "tenant or scopes")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !auth.Scopes[scope] {
+			msg := fmt.Sprintf("Invalid parameter (unknown scope %v)", scope)
+			log.Error().Msgf(fmtError, msg)
+			respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+			respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "scopes")
+			rest.WriteResponse(w, http.StatusBadRequest, respErr)
+			return
+		}
+	}
+
+	// step: store the key, only the hash of its secret
+	key, token, err := auth.NewKey(request.Tenant, request.Scopes, s.now())
+	if err == nil {
+		err = s.Repository.CreateKey(ctx, key)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	item := newItem(key)
+	item.Token = token
+
+	fmt.Println("CreateKey : Success")
+	_ = rest.WriteResponse(w, http.StatusCreated, &rest.Response{
+		Code:    201,
+		Message: "Success",
+		Data:    item,
+	})
+
+	return
+}
+
+func (s *StorageService) ListKeys(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("ListKeys")
+
+	ctx := r.Context()
+
+	keys, err := s.Repository.ListKeys(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		rest.WriteResponse(w, http.StatusBadRequest, &rest.ErrorResponse{
+			Error: rest.Response{
+				Code:    rest.ErrCodeRedis["Code"].(int),
+				Message: rest.ErrCodeRedis["Message"].(string),
+			},
+		})
+		return
+	}
+
+	items := make([]KeyItem, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, newItem(key))
+	}
+
+	fmt.Println("ListKeys : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data:    items,
+	})
+
+	return
+}
+
+// DeleteKey revokes an API key, requests with its token are refused right away
+func (s *StorageService) DeleteKey(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("DeleteKey")
+
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	err := s.Repository.DeleteKey(ctx, id)
+	if err == repository.ErrNotFound {
+		msg := fmt.Sprintf("key not found (%v)", id)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeNotfound["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeNotfound["Message"].(string)
+		rest.WriteResponse(w, http.StatusNotFound, respErr)
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	fmt.Println("DeleteKey : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+	})
+
+	return
+}
+
+func newItem(key repository.APIKey) KeyItem {
+	return KeyItem{
+		ID:        key.ID,
+		Tenant:    key.Tenant,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+}
@@ -0,0 +1,164 @@
+package keying
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockKeys *mockrepository.MockKeyRepository
+)
+
+func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
+	mockKeys = mockrepository.NewMockKeyRepository(ctrl)
+
+	return StorageService{
+		Repository: mockKeys,
+		now:        func() time.Time { return time.Unix(1619766384, 0) },
+	}
+}
+
+func (s *TSuite) TestCreate_InvalidTenant() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"tenant": "acme corp", "scopes": ["create"]}`))
+	StorageService.CreateKey(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestCreate_UnknownScope() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"tenant": "acme", "scopes": ["create", "root"]}`))
+	StorageService.CreateKey(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestCreate_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	var stored repository.APIKey
+	mockKeys.EXPECT().CreateKey(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, key repository.APIKey) error {
+		stored = key
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"tenant": "acme", "scopes": ["create", "read-stats"]}`))
+	StorageService.CreateKey(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusCreated, w.Code)
+	s.Assert().Equal("acme", stored.Tenant)
+	s.Assert().Equal([]string{"create", "read-stats"}, stored.Scopes)
+	s.Assert().Equal(int64(1619766384), stored.CreatedAt)
+	s.Assert().Contains(string(body), `"id":"`+stored.ID+`","tenant":"acme","scopes":["create","read-stats"],"created_at":1619766384,"key":"`+stored.ID+`.`)
+	s.Assert().NotContains(string(body), stored.Hash)
+}
+
+func (s *TSuite) TestCreate_StorageError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockKeys.EXPECT().CreateKey(gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodPost, "/admin/keys", strings.NewReader(`{"tenant": "acme", "scopes": ["admin"]}`))
+	StorageService.CreateKey(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
+}
+
+func (s *TSuite) TestList_HidesHashes() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockKeys.EXPECT().ListKeys(gomock.Any()).
+		Return([]repository.APIKey{{ID: "k1", Tenant: "acme", Hash: "secret-hash", Scopes: []string{auth.ScopeCreate}, CreatedAt: 1619766384}}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+	StorageService.ListKeys(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `[{"id":"k1","tenant":"acme","scopes":["create"],"created_at":1619766384}]`)
+	s.Assert().NotContains(string(body), "secret-hash")
+}
+
+func (s *TSuite) TestDelete_NotFound() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockKeys.EXPECT().DeleteKey(gomock.Any(), "k1").Return(repository.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/admin/keys/k1", nil), map[string]string{"id": "k1"})
+	StorageService.DeleteKey(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
+
+func (s *TSuite) TestDelete_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockKeys.EXPECT().DeleteKey(gomock.Any(), "k1").Return(nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/admin/keys/k1", nil), map[string]string{"id": "k1"})
+	StorageService.DeleteKey(w, testRequest)
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+}
@@ -0,0 +1,15 @@
+package keying
+
+type KeyRequest struct {
+	Tenant string   `json:"tenant" valid:"required"`
+	Scopes []string `json:"scopes" valid:"required"`
+}
+
+// KeyItem describes an API key, its token is only shown in the response of CreateKey
+type KeyItem struct {
+	ID        string   `json:"id"`
+	Tenant    string   `json:"tenant"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt int64    `json:"created_at"`
+	Token     string   `json:"key,omitempty"`
+}
@@ -0,0 +1,153 @@
+package listing
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository"
+)
+
+const (
+	fmtError     = "%v"
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Service interface {
+	ListUrlShortener(w http.ResponseWriter, r *http.Request)
+}
+
+type StorageService struct {
+	Repository repository.LinkRepository
+	Domains    *domain.Domains
+}
+
+func NewService(links repository.LinkRepository, domains *domain.Domains) Service {
+	return &StorageService{
+		Repository: links,
+		Domains:    domains,
+	}
+}
+
+// ListUrlShortener lists stored links page by page. The cursor query parameter continues from the
+// previous page and limit is a page size hint, a page may hold a few more items than requested.
+// Links can be filtered by short code prefix (code) and by a case-insensitive keyword on the full url.
+// The domain parameter restricts the list to the links of a configured domain.
+// Callers only see the links of their tenant, every link with the admin scope.
+func (s *StorageService) ListUrlShortener(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("ListUrlShortener")
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	// step: validate query
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "limit")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	filter := repository.ListFilter{
+		Cursor:     query.Get("cursor"),
+		Limit:      limit,
+		CodePrefix: query.Get("code"),
+		Keyword:    query.Get("keyword"),
+	}
+	if host := query.Get("domain"); host != "" {
+		site := s.Domains.Named(host)
+		if site == nil {
+			msg := fmt.Sprintf("Invalid parameter (domain %v is not configured)", host)
+			log.Error().Msgf(fmtError, msg)
+			respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+			respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "domain")
+			rest.WriteResponse(w, http.StatusBadRequest, respErr)
+			return
+		}
+		filter.CodePrefix = site.Code(filter.CodePrefix)
+	}
+	principal := auth.FromContext(ctx)
+	if principal == nil || (!principal.Has(auth.ScopeAdmin) && principal.Tenant == "") {
+		msg := "list without tenant"
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeForbidden["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeForbidden["Message"].(string), "the caller has no tenant")
+		rest.WriteResponse(w, http.StatusForbidden, respErr)
+		return
+	}
+	if !principal.Has(auth.ScopeAdmin) {
+		filter.Owner = principal.Tenant
+	}
+
+	// step: read one page
+	links, cursor, err := s.Repository.List(ctx, filter)
+	if err == repository.ErrInvalidCursor {
+		msg := fmt.Sprintf("Invalid parameter (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "cursor")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	items := make([]UrlItem, 0, len(links))
+	for _, link := range links {
+		namespace, code := domain.Split(link.Code)
+		items = append(items, UrlItem{
+			ShortCode:    code,
+			FullURL:      link.FullURL,
+			ExpireDate:   link.ExpireAt,
+			NumberOfHits: link.MaxHits,
+			Hits:         link.Hits,
+			Version:      link.Version,
+			Owner:        link.Owner,
+			Flagged:      link.Flagged,
+			Domain:       s.Domains.ForNamespace(namespace, "").Host,
+		})
+	}
+
+	fmt.Println("ListUrlShortener : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data: &ListResponse{
+			Items:  items,
+			Cursor: cursor,
+		},
+	})
+
+	return
+}
+
+func parseLimit(limitParam string) (int, error) {
+	if limitParam == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %v", maxLimit)
+	}
+	return limit, nil
+}
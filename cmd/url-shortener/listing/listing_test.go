@@ -0,0 +1,197 @@
+package listing
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockRepository *mockrepository.MockLinkRepository
+)
+
+func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
+
+	return StorageService{
+		Repository: mockRepository,
+	}
+}
+
+// asAdmin authenticates r with the admin token
+func asAdmin(r *http.Request) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), auth.Admin))
+}
+
+func (s *TSuite) TestList_InvalidLimit() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls?limit=1000", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestList_InvalidCursor() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, "", repository.ErrInvalidCursor)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls?cursor=abc", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
+
+func (s *TSuite) TestList_StorageError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: 20}).Return(nil, "", errors.New("redis error"))
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
+}
+
+func (s *TSuite) TestList_Success() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Cursor: "7", Limit: 2, CodePrefix: "ab", Keyword: "speedtest"}).
+		Return([]repository.Link{{Code: "abc", FullURL: "https://www.SpeedTest.net", ExpireAt: 4102444800, MaxHits: 10, Hits: 3, Version: 2}}, "34", nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls?cursor=7&limit=2&code=ab&keyword=speedtest", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"items":[{"short_code":"abc","full_url":"https://www.SpeedTest.net","expire_date":4102444800,"number_of_hits":10,"hits":3,"version":2}]`)
+	s.Assert().Contains(string(body), `"cursor":"34"`)
+}
+
+func (s *TSuite) TestList_LastPage() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, "", nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"items":[]`)
+	s.Assert().NotContains(string(body), `"cursor"`)
+}
+
+func (s *TSuite) TestList_OwnLinksOnly() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: 20, Owner: "acme"}).Return(nil, "", nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls", nil)
+	principal := &auth.Principal{KeyID: "k1", Tenant: "acme", Scopes: []string{auth.ScopeReadStats}}
+	StorageService.ListUrlShortener(w, testRequest.WithContext(auth.NewContext(testRequest.Context(), principal)))
+
+	s.Assert().Equal(http.StatusOK, w.Code)
+}
+
+func (s *TSuite) TestList_Unauthenticated() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls", nil)
+	StorageService.ListUrlShortener(w, testRequest)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(string(body), `"code":1018`)
+}
+
+func (s *TSuite) TestList_Domain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	domains, err := domain.New([]domain.Config{{Host: "go.example.com", Namespace: "go"}})
+	s.Require().NoError(err)
+	StorageService.Domains = domains
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: 20, CodePrefix: "go/ab"}).
+		Return([]repository.Link{{Code: "go/abc", FullURL: "https://www.speedtest.net"}}, "", nil)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls?domain=go.example.com&code=ab", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"short_code":"abc"`)
+	s.Assert().Contains(string(body), `"domain":"go.example.com"`)
+}
+
+func (s *TSuite) TestList_UnknownDomain() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls?domain=go.example.com", nil)
+	StorageService.ListUrlShortener(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1001`)
+}
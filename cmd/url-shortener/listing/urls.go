@@ -0,0 +1,21 @@
+package listing
+
+type UrlItem struct {
+	ShortCode    string `json:"short_code"`
+	FullURL      string `json:"full_url"`
+	ExpireDate   int64  `json:"expire_date,omitempty"`
+	NumberOfHits int64  `json:"number_of_hits"`
+	Hits         int64  `json:"hits"`
+	Version      int64  `json:"version"`
+	Owner        string `json:"owner,omitempty"`
+	// Flagged is the threat type reported for the destination, the link does not redirect while it is flagged
+	Flagged string `json:"flagged,omitempty"`
+	// Domain is the host the short code is served on, missing for links of no configured domain
+	Domain string `json:"domain,omitempty"`
+}
+
+// ListResponse is a page of links, Cursor is left out on the last page
+type ListResponse struct {
+	Items  []UrlItem `json:"items"`
+	Cursor string    `json:"cursor,omitempty"`
+}
@@ -4,18 +4,42 @@ import (
 	"context"
 	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/newrelic/go-agent/_integrations/nrgorilla/v1"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"url-shortener/cmd/url-shortener/blacklisting"
+	"url-shortener/cmd/url-shortener/caching"
+	"url-shortener/cmd/url-shortener/checking"
 	"url-shortener/cmd/url-shortener/deleting"
 	"url-shortener/cmd/url-shortener/generate"
 	"url-shortener/cmd/url-shortener/getting"
+	"url-shortener/cmd/url-shortener/keying"
+	"url-shortener/cmd/url-shortener/listing"
+	"url-shortener/cmd/url-shortener/reporting"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/blacklist"
+	"url-shortener/internal/clientip"
 	"url-shortener/internal/config"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/generate/encode"
+	"url-shortener/internal/health"
+	"url-shortener/internal/http/middleware"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/cache"
+	"url-shortener/internal/repository/database"
 	"url-shortener/internal/repository/redis"
+	"url-shortener/internal/reputation"
+	"url-shortener/internal/tracing"
+	"url-shortener/internal/urlpolicy"
 )
 
 func main() {
@@ -34,17 +58,135 @@ func run() error {
 		log.Fatal().Msgf("Unexpected error to init configuration: %v.", err)
 	}
 
-	redisHandler := &redis.Handler{}
-	if err := redisHandler.Connect(conf.Redis); err != nil {
+	// spans are only exported when an exporter is configured, trace context is propagated either way
+	if conf.Tracing.ServiceName == "" {
+		conf.Tracing.ServiceName = conf.Name
+	}
+	tracer, err := tracing.New(conf.Tracing)
+	if err != nil {
+		return err
+	}
+
+	stores, err := openStorage(conf)
+	if err != nil {
+		return err
+	}
+	links := stores.links
+
+	// readiness lists every dependency the service can not serve without
+	readiness := health.NewChecker(conf.Health.Timeout * time.Second)
+	readiness.Register(stores.name, stores.ping)
+
+	list, err := blacklist.New(conf.Blacklist, stores.patterns)
+	if err != nil {
 		return err
 	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go list.Watch(watchCtx, conf.Blacklist.ReloadInterval*time.Second)
+
+	// every service shares the cached repository so writes invalidate the cache in-process
+	storage := links
+	var cached *cache.Repository
+	if conf.Cache.Size > 0 {
+		cached = cache.NewRepository(links, cache.Config{
+			Size:        conf.Cache.Size,
+			TTL:         conf.Cache.TTL * time.Second,
+			NegativeTTL: conf.Cache.NegativeTTL * time.Second,
+			WarmTimeout: conf.Cache.WarmTimeout * time.Second,
+		})
+		storage = cached
+		// the server starts cold, readiness fails until the most visited links are cached or the warm up timed out
+		readiness.Register("cache", cached.Ready)
+		go func() {
+			if err := cached.Warm(watchCtx); err != nil {
+				log.Warn().Msgf("Unexpected error to warm the cache: %v", err)
+			}
+		}()
+		metrics.RegisterCache(func() (uint64, uint64) {
+			stats := cached.Stats()
+			return stats.Hits, stats.Misses
+		})
+	}
 
-	service := generate.NewService(redisHandler, conf.Redis)
-	getter := getting.NewService(redisHandler, conf.Redis)
-	deleter := deleting.NewService(redisHandler, conf.Redis)
+	domains, err := domain.New(conf.Domains)
+	if err != nil {
+		return err
+	}
+
+	// destinations are checked against the reputation file when one is configured
+	var checker reputation.Checker
+	if conf.Reputation.File != "" {
+		threats, err := reputation.NewList(conf.Reputation)
+		if err != nil {
+			return err
+		}
+		go threats.Watch(watchCtx, conf.Reputation.ReloadInterval*time.Second)
+		// the sweeper flags through the cached repository so redirects see the flag at once
+		go reputation.NewSweeper(storage, threats, conf.Reputation.SweepInterval*time.Second).Run(watchCtx)
+		checker = threats
+	}
+
+	generator, err := encode.NewGenerator(conf.Generator, stores.counter, conf.Redis.Key+"counter")
+	if err != nil {
+		return err
+	}
+
+	// clients are told apart by their address, read from X-Forwarded-For behind trusted proxies only
+	proxies, err := clientip.New(conf.RateLimit.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if _, err := clientip.New(conf.Analytics.TrustedProxies); err != nil {
+		return err
+	}
+
+	// clicks are written by background workers, they drain the buffer on shutdown
+	recorder := analytics.NewRecorder(stores.stats, analytics.NoLocator{}, conf.Analytics)
+	recorderCtx, stopRecorder := context.WithCancel(context.Background())
+	recorderDone := make(chan struct{})
+	go func() {
+		recorder.Run(recorderCtx)
+		close(recorderDone)
+	}()
+
+	service := generate.NewService(storage, list, generator, conf.Generator, stores.index, conf.Idempotency.TTL*time.Second, generate.ExpiryPolicy{
+		Default: conf.Redis.DefaultExpiry(),
+		Max:     conf.Redis.MaxExpiry(),
+	}, domains, urlpolicy.New(conf.URLPolicy, domains.Hosts()...), checker, conf.QR.Size)
+	getter := getting.NewService(storage, list, recorder, ratelimit.NewLimiter(stores.rates, ratelimit.Config{
+		Limit:  conf.Unlock.Limit,
+		Window: conf.Unlock.Window * time.Second,
+	}), middleware.ClientIP(proxies), domains, getting.QRPolicy{
+		Size:    conf.QR.Size,
+		MaxSize: conf.QR.MaxSize,
+	}, cache.NewImages(cache.Config{
+		Size: conf.QR.Cache.Size,
+		TTL:  conf.QR.Cache.TTL * time.Second,
+	}))
+	deleter := deleting.NewService(storage, domains)
+	// listing reads hit counters, which the cache may hold stale
+	lister := listing.NewService(links, domains)
+	blacklister := blacklisting.NewService(list)
+	cacher := caching.NewService(cached)
+	reporter := reporting.NewService(links, stores.stats, domains)
+	keyer := keying.NewService(stores.keys)
+	prober := checking.NewService(readiness)
+
+	limits := rateLimits{
+		generate: ratelimit.NewSlidingWindow(stores.rates, ratelimit.Config{
+			Limit:  conf.RateLimit.Generate.Limit,
+			Window: conf.RateLimit.Generate.Window * time.Second,
+		}),
+		redirect: ratelimit.NewSlidingWindow(stores.rates, ratelimit.Config{
+			Limit:  conf.RateLimit.Redirect.Limit,
+			Window: conf.RateLimit.Redirect.Window * time.Second,
+		}),
+		client: middleware.Client(proxies),
+	}
 
 	server := &http.Server{
-		Handler:      routes(service, getter, deleter),
+		Handler:      routes(service, getter, deleter, lister, blacklister, cacher, reporter, keyer, middleware.Authenticate(stores.keys, conf.Admin.Token), limits, tracer, prober),
 		Addr:         fmt.Sprintf(":%v", conf.Port),
 		WriteTimeout: conf.Timeout * time.Second,
 		ReadTimeout:  conf.Timeout * time.Second,
@@ -63,6 +205,10 @@ func run() error {
 	<-done
 	log.Info().Msg("Server Stopped")
 
+	// load balancers see readiness fail and stop sending requests before connections are drained
+	readiness.Drain()
+	time.Sleep(conf.Health.DrainDelay * time.Second)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer func() {
 		// extra handling here
@@ -72,20 +218,118 @@ func run() error {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatal().Msgf("Server Shutdown Failed:%+v", err)
 	}
+	stopRecorder()
+	<-recorderDone
+	// without exporter the provider holds no spans, and has no processor to shut down
+	if conf.Tracing.Exporter != "" {
+		tracerCtx, cancelTracer := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelTracer()
+		if err := tracer.Shutdown(tracerCtx); err != nil {
+			log.Warn().Msgf("Unexpected error to flush spans: %v", err)
+		}
+	}
 	log.Info().Msg("Server Exited Properly")
 
 	return nil
 }
 
-func routes(generate generate.Service, getter getting.Service, deleter deleting.Service) *mux.Router {
+// backend is the storage selected by conf.Storage
+type backend struct {
+	links   repository.LinkRepository
+	counter repository.Counter
+	stats   repository.StatsRepository
+	index   repository.IndexRepository
+	keys    repository.KeyRepository
+	// patterns shares the blacklist patterns added at runtime between instances
+	patterns repository.PatternRepository
+	// rates counts rate limited requests, nil counts them in memory
+	rates ratelimit.Store
+	// name is the dependency checked by ping for readiness
+	name string
+	ping health.Check
+}
+
+// openStorage connects the backend selected by conf.Storage, Redis is the default
+func openStorage(conf *Config) (*backend, error) {
+	switch conf.Storage.Backend {
+	case "", repository.BackendRedis:
+		redisHandler := &redis.Handler{}
+		if err := redisHandler.Connect(conf.Redis); err != nil {
+			return nil, err
+		}
+		return &backend{
+			links:    redis.NewLinkRepository(redisHandler, conf.Redis),
+			counter:  redisHandler,
+			stats:    redis.NewStatsRepository(redisHandler, conf.Redis, time.Duration(conf.Analytics.Retention)*24*time.Hour),
+			index:    redis.NewIndexRepository(redisHandler, conf.Redis),
+			keys:     redis.NewKeyRepository(redisHandler, conf.Redis),
+			patterns: redis.NewPatternRepository(redisHandler, conf.Redis),
+			rates:    redis.NewRateRepository(redisHandler, conf.Redis),
+			name:     repository.BackendRedis,
+			ping:     redisHandler.Ping,
+		}, nil
+	case repository.BackendSQL:
+		links, err := database.Open(conf.Storage)
+		if err != nil {
+			return nil, err
+		}
+		return &backend{links: links, counter: links, stats: links, index: links, keys: links, patterns: links, name: "database", ping: links.Ping}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", conf.Storage.Backend)
+	}
+}
+
+// rateLimits are the limits of the public routes, the admin api is not limited
+type rateLimits struct {
+	generate *ratelimit.SlidingWindow
+	redirect *ratelimit.SlidingWindow
+	client   func(r *http.Request) string
+}
+
+// routes wires the handlers, authenticate puts the caller of a token in the request context
+// and the scope of every protected route is checked after it. Every request is served in a span of tracer.
+func routes(generate generate.Service, getter getting.Service, deleter deleting.Service, lister listing.Service, blacklister blacklisting.Service, cacher caching.Service, reporter reporting.Service, keyer keying.Service, authenticate func(http.Handler) http.Handler, limits rateLimits, tracer trace.TracerProvider, prober checking.Service) *mux.Router {
 
 	route := mux.NewRouter()
 
-	route.HandleFunc("/{code}", getter.GetUrlShortener).Methods(http.MethodGet)
-	route.HandleFunc("/generate", generate.GenerateUrlShortener).Methods(http.MethodPost)
-	route.HandleFunc("/{code}", deleter.DeleteUrlShortener).Methods(http.MethodDelete)
+	limitGenerate := middleware.RateLimit(limits.generate, "generate", limits.client)
+	limitRedirect := middleware.RateLimit(limits.redirect, "redirect", limits.client)
+	scoped := func(scope string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequireScope(scope)(handler)
+	}
+
+	// every route is counted per path template, /metrics and the probes are matched before /{code} takes them as a code
+	route.Use(middleware.Trace(tracer), middleware.Metrics)
+	route.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	route.HandleFunc("/healthz", prober.Healthz).Methods(http.MethodGet)
+	route.HandleFunc("/readyz", prober.Readyz).Methods(http.MethodGet)
+
+	// codes never hold a +, /{code}+ is matched before /{code} takes it in the code
+	route.Handle("/{code}+", limitRedirect(http.HandlerFunc(getter.PreviewUrlShortener))).Methods(http.MethodGet, http.MethodPost)
+	route.Handle("/{code}", limitRedirect(http.HandlerFunc(getter.GetUrlShortener))).Methods(http.MethodGet)
+	route.Handle("/{code}/qr", limitRedirect(http.HandlerFunc(getter.QRUrlShortener))).Methods(http.MethodGet)
+	// generate limits per API key, so it runs after authenticate
+	route.Handle("/generate", authenticate(middleware.RequireScope(auth.ScopeCreate)(limitGenerate(http.HandlerFunc(generate.GenerateUrlShortener))))).Methods(http.MethodPost)
+	route.Handle("/generate/batch", authenticate(middleware.RequireScope(auth.ScopeCreate)(limitGenerate(http.HandlerFunc(generate.GenerateBatch))))).Methods(http.MethodPost)
+	// the unlock form of protected links posts back to the short url, after /generate
+	route.Handle("/{code}", limitRedirect(http.HandlerFunc(getter.GetUrlShortener))).Methods(http.MethodPost)
+	route.Handle("/{code}", authenticate(scoped(auth.ScopeDelete, deleter.DeleteUrlShortener))).Methods(http.MethodDelete)
+
+	adminRoute := route.PathPrefix("/admin").Subrouter()
+	adminRoute.Use(authenticate)
+	adminRoute.Handle("/urls", scoped(auth.ScopeReadStats, lister.ListUrlShortener)).Methods(http.MethodGet)
+	adminRoute.Handle("/urls/{code}", scoped(auth.ScopeDelete, deleter.DeleteUrlShortener)).Methods(http.MethodDelete)
+	adminRoute.Handle("/urls/{code}", scoped(auth.ScopeCreate, generate.UpdateUrlShortener)).Methods(http.MethodPatch)
+	adminRoute.Handle("/urls/{code}/stats", scoped(auth.ScopeReadStats, reporter.GetUrlStats)).Methods(http.MethodGet)
+	adminRoute.Handle("/blacklist", scoped(auth.ScopeAdmin, blacklister.ListPatterns)).Methods(http.MethodGet)
+	adminRoute.Handle("/blacklist", scoped(auth.ScopeAdmin, blacklister.AddPattern)).Methods(http.MethodPost)
+	adminRoute.Handle("/blacklist", scoped(auth.ScopeAdmin, blacklister.RemovePattern)).Methods(http.MethodDelete)
+	adminRoute.Handle("/cache", scoped(auth.ScopeAdmin, cacher.CacheStats)).Methods(http.MethodGet)
+	adminRoute.Handle("/keys", scoped(auth.ScopeAdmin, keyer.ListKeys)).Methods(http.MethodGet)
+	adminRoute.Handle("/keys", scoped(auth.ScopeAdmin, keyer.CreateKey)).Methods(http.MethodPost)
+	adminRoute.Handle("/keys/{id}", scoped(auth.ScopeAdmin, keyer.DeleteKey)).Methods(http.MethodDelete)
 
 	route.StrictSlash(false)
 
-	return nrgorilla.InstrumentRoutes(route, nil)
+	return route
 }
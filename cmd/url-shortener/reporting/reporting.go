@@ -0,0 +1,113 @@
+package reporting
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/domain"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository"
+)
+
+const fmtError = "%v"
+
+type Service interface {
+	GetUrlStats(w http.ResponseWriter, r *http.Request)
+}
+
+type StorageService struct {
+	Repository repository.LinkRepository
+	Stats      repository.StatsRepository
+	Domains    *domain.Domains
+}
+
+func NewService(links repository.LinkRepository, stats repository.StatsRepository, domains *domain.Domains) Service {
+	return &StorageService{
+		Repository: links,
+		Stats:      stats,
+		Domains:    domains,
+	}
+}
+
+// GetUrlStats returns the click totals of a link with per-day, per-referrer and per-country breakdowns.
+// Deleted links keep their stats, only callers with the admin scope read them. The code is looked up on the
+// domain named by the domain parameter, on the domain of the Host header without it.
+func (s *StorageService) GetUrlStats(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("GetUrlStats")
+
+	ctx := r.Context()
+	code := mux.Vars(r)["code"]
+	site := s.Domains.Select(r.URL.Query().Get("domain"), r.Host)
+
+	respErr := &rest.ErrorResponse{
+		Error: rest.Response{},
+	}
+
+	// step: the domain parameter names the domain of the code
+	if site == nil {
+		msg := fmt.Sprintf("Invalid parameter (domain %v is not configured)", r.URL.Query().Get("domain"))
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeBadRequest["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeBadRequest["Message"].(string), "domain")
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+	stored := site.Code(code)
+
+	// step: make sure the link exists
+	link, err := s.Repository.Get(ctx, stored)
+	if err == repository.ErrNotFound {
+		msg := fmt.Sprintf("url not found (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeNotfound["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeNotfound["Message"].(string)
+		rest.WriteResponse(w, http.StatusNotFound, respErr)
+		return
+	} else if err != nil && err != repository.ErrDeleted {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	// step: only the owner reads the stats, the owner of a deleted link is not known anymore
+	owner := ""
+	if link != nil {
+		owner = link.Owner
+	}
+	if !auth.CanManage(ctx, owner) {
+		msg := fmt.Sprintf("url of another tenant (%v)", code)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeForbidden["Code"].(int)
+		respErr.Error.Message = fmt.Sprintf(rest.ErrCodeForbidden["Message"].(string), "the url belongs to another tenant")
+		rest.WriteResponse(w, http.StatusForbidden, respErr)
+		return
+	}
+
+	// step: read the counters
+	stats, err := s.Stats.Stats(ctx, stored)
+	if err != nil {
+		msg := fmt.Sprintf("storage error (%v)", err)
+		log.Error().Msgf(fmtError, msg)
+		respErr.Error.Code = rest.ErrCodeRedis["Code"].(int)
+		respErr.Error.Message = rest.ErrCodeRedis["Message"].(string)
+		rest.WriteResponse(w, http.StatusBadRequest, respErr)
+		return
+	}
+
+	fmt.Println("GetUrlStats : Success")
+	_ = rest.WriteResponse(w, http.StatusOK, &rest.Response{
+		Code:    200,
+		Message: "Success",
+		Data: &StatsResponse{
+			ShortCode: code,
+			LinkStats: *stats,
+		},
+	})
+
+	return
+}
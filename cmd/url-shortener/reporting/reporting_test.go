@@ -0,0 +1,120 @@
+package reporting
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockRepository *mockrepository.MockLinkRepository
+	mockStats      *mockrepository.MockStatsRepository
+)
+
+func setUpServiceMocking(ctrl *gomock.Controller) StorageService {
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
+	mockStats = mockrepository.NewMockStatsRepository(ctrl)
+
+	return StorageService{
+		Repository: mockRepository,
+		Stats:      mockStats,
+	}
+}
+
+// asAdmin authenticates r with the admin token
+func asAdmin(r *http.Request) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), auth.Admin))
+}
+
+func (s *TSuite) TestStats_NotFound() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, repository.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls/code/stats", nil)
+	StorageService.GetUrlStats(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusNotFound, w.Code)
+	s.Assert().Contains(string(body), `"code":1006`)
+}
+
+func (s *TSuite) TestStats_StorageError() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&repository.Link{}, nil)
+	mockStats.EXPECT().Stats(gomock.Any(), gomock.Any()).Return(nil, errors.New("redis error"))
+
+	w := httptest.NewRecorder()
+	testRequest := httptest.NewRequest(http.MethodGet, "/admin/urls/code/stats", nil)
+	StorageService.GetUrlStats(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusBadRequest, w.Code)
+	s.Assert().Contains(string(body), `"code":1004`)
+	s.Assert().NotContains(string(body), "redis error")
+}
+
+func (s *TSuite) TestStats_DeletedLinkKeepsStats() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrDeleted)
+	mockStats.EXPECT().Stats(gomock.Any(), "code").Return(&repository.LinkStats{
+		Total:     2,
+		Days:      map[string]int64{"2021-04-30": 2},
+		Referrers: map[string]int64{"direct": 2},
+		Countries: map[string]int64{"unknown": 2},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/admin/urls/code/stats", nil), map[string]string{"code": "code"})
+	StorageService.GetUrlStats(w, asAdmin(testRequest))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusOK, w.Code)
+	s.Assert().Contains(string(body), `"data":{"short_code":"code","total":2,"days":{"2021-04-30":2},"referrers":{"direct":2},"countries":{"unknown":2}}`)
+}
+
+func (s *TSuite) TestStats_OtherTenant() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	StorageService := setUpServiceMocking(ctrl)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(&repository.Link{Code: "code", Owner: "acme"}, nil)
+
+	w := httptest.NewRecorder()
+	testRequest := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/admin/urls/code/stats", nil), map[string]string{"code": "code"})
+	principal := &auth.Principal{KeyID: "k1", Tenant: "globex", Scopes: []string{auth.ScopeReadStats}}
+	StorageService.GetUrlStats(w, testRequest.WithContext(auth.NewContext(testRequest.Context(), principal)))
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	s.Assert().Equal(http.StatusForbidden, w.Code)
+	s.Assert().Contains(string(body), `"code":1018`)
+}
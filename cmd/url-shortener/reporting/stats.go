@@ -0,0 +1,8 @@
+package reporting
+
+import "url-shortener/internal/repository"
+
+type StatsResponse struct {
+	ShortCode string `json:"short_code"`
+	repository.LinkStats
+}
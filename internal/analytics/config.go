@@ -0,0 +1,10 @@
+package analytics
+
+type Config struct {
+	Buffer  int
+	Workers int
+	Salt    string
+	// TrustedProxies are the addresses or CIDRs of the proxies whose X-Forwarded-For entries are read
+	TrustedProxies []string
+	Retention      int64
+}
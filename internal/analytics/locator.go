@@ -0,0 +1,16 @@
+package analytics
+
+import "net"
+
+// Locator resolves the country of a client ip, implementations plug in a GeoIP database.
+// An empty country means unknown.
+type Locator interface {
+	Country(ip net.IP) string
+}
+
+// NoLocator leaves every country unknown
+type NoLocator struct{}
+
+func (NoLocator) Country(ip net.IP) string {
+	return ""
+}
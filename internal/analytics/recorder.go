@@ -0,0 +1,183 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/rs/zerolog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/internal/clientip"
+	"url-shortener/internal/repository"
+)
+
+const (
+	defaultBuffer  = 1000
+	defaultWorkers = 1
+	maxUserAgent   = 512
+
+	DirectReferrer = "direct"
+	UnknownCountry = "unknown"
+)
+
+var (
+	consoleLog zerolog.Logger
+)
+
+// Recorder turns redirects into clicks and writes them to a StatsRepository from background
+// workers, the redirect never waits for the write. A nil *Recorder records nothing.
+type Recorder struct {
+	// counter first to keep it 64-bit aligned for sync/atomic
+	dropped uint64
+
+	Store   repository.StatsRepository
+	Locator Locator
+	salt    string
+	proxies *clientip.Resolver
+	workers int
+	clicks  chan repository.Click
+	now     func() time.Time
+}
+
+func NewRecorder(store repository.StatsRepository, locator Locator, config Config) *Recorder {
+	buffer := config.Buffer
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if locator == nil {
+		locator = NoLocator{}
+	}
+	// the proxies are checked when the config is read, a broken list trusts none
+	proxies, err := clientip.New(config.TrustedProxies)
+	if err != nil {
+		consoleLog.Warn().Msgf("Unexpected error to read trusted proxies: %v", err)
+	}
+
+	return &Recorder{
+		Store:   store,
+		Locator: locator,
+		salt:    config.Salt,
+		proxies: proxies,
+		workers: workers,
+		clicks:  make(chan repository.Click, buffer),
+		now:     time.Now,
+	}
+}
+
+// Record queues a click on code made by r. It reports false when the buffer is full and the
+// click was dropped.
+func (rec *Recorder) Record(r *http.Request, code string) bool {
+	if rec == nil {
+		return false
+	}
+
+	select {
+	case rec.clicks <- rec.click(r, code):
+		return true
+	default:
+		atomic.AddUint64(&rec.dropped, 1)
+		return false
+	}
+}
+
+// Dropped returns how many clicks were lost to a full buffer
+func (rec *Recorder) Dropped() uint64 {
+	if rec == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&rec.dropped)
+}
+
+// Run writes queued clicks until ctx is done, then writes what is left in the buffer and returns
+func (rec *Recorder) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < rec.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (rec *Recorder) work(ctx context.Context) {
+	for {
+		select {
+		case click := <-rec.clicks:
+			rec.write(click)
+		case <-ctx.Done():
+			for {
+				select {
+				case click := <-rec.clicks:
+					rec.write(click)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write stores click, clicks are still written while draining so it does not take the context of the workers
+func (rec *Recorder) write(click repository.Click) {
+	if err := rec.Store.RecordClick(context.Background(), click); err != nil {
+		consoleLog.Warn().Msgf("Unexpected error to record click on: %v, err: %v", click.Code, err)
+	}
+}
+
+func (rec *Recorder) click(r *http.Request, code string) repository.Click {
+	now := rec.now().UTC()
+	ip := rec.clientIP(r)
+
+	country := ""
+	if parsed := net.ParseIP(ip); parsed != nil {
+		country = rec.Locator.Country(parsed)
+	}
+	if country == "" {
+		country = UnknownCountry
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+
+	return repository.Click{
+		Code:      code,
+		Timestamp: now.Unix(),
+		Day:       now.Format("2006-01-02"),
+		Referrer:  referrerHost(r.Referer()),
+		UserAgent: userAgent,
+		Country:   country,
+		IPHash:    rec.hashIP(ip),
+	}
+}
+
+// clientIP returns the address of the visitor, X-Forwarded-For is only read from trusted proxies
+func (rec *Recorder) clientIP(r *http.Request) string {
+	return rec.proxies.IP(r)
+}
+
+// hashIP keeps visitors apart without storing their address
+func (rec *Recorder) hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(rec.salt + ip))
+	return hex.EncodeToString(sum[:16])
+}
+
+func referrerHost(referrer string) string {
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Host == "" {
+		return DirectReferrer
+	}
+	return strings.ToLower(parsed.Hostname())
+}
@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type countryOf map[string]string
+
+func (c countryOf) Country(ip net.IP) string {
+	return c[ip.String()]
+}
+
+func TestRecorder_BuildsClick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockrepository.NewMockStatsRepository(ctrl)
+	rec := NewRecorder(store, countryOf{"203.0.113.7": "TH"}, Config{Salt: "pepper", TrustedProxies: []string{"10.0.0.0/8"}})
+	rec.now = func() time.Time { return time.Unix(1619766384, 0) }
+
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("Referer", "https://News.Example.com/article?id=1")
+	r.Header.Set("User-Agent", "curl/7.68.0")
+	r.RemoteAddr = "10.0.0.2:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	click := rec.click(r, "abc")
+	assert.Equal(t, repository.Click{
+		Code:      "abc",
+		Timestamp: 1619766384,
+		Day:       "2021-04-30",
+		Referrer:  "news.example.com",
+		UserAgent: "curl/7.68.0",
+		Country:   "TH",
+		IPHash:    rec.hashIP("203.0.113.7"),
+	}, click)
+	assert.Len(t, click.IPHash, 32)
+	assert.NotEqual(t, NewRecorder(store, nil, Config{Salt: "salt"}).hashIP("203.0.113.7"), click.IPHash)
+}
+
+func TestRecorder_IgnoresForwardedForByDefault(t *testing.T) {
+	rec := NewRecorder(nil, nil, Config{})
+
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.RemoteAddr = "192.0.2.1:51234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	click := rec.click(r, "abc")
+	assert.Equal(t, rec.hashIP("192.0.2.1"), click.IPHash)
+	assert.Equal(t, DirectReferrer, click.Referrer)
+	assert.Equal(t, UnknownCountry, click.Country)
+}
+
+func TestRecorder_WritesInBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockrepository.NewMockStatsRepository(ctrl)
+	store.EXPECT().RecordClick(gomock.Any(), gomock.Any()).Return(nil)
+	store.EXPECT().RecordClick(gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
+	rec := NewRecorder(store, nil, Config{Buffer: 2, Workers: 2})
+
+	assert.True(t, rec.Record(httptest.NewRequest("GET", "/abc", nil), "abc"))
+	assert.True(t, rec.Record(httptest.NewRequest("GET", "/abc", nil), "abc"))
+	// the buffer is full until a worker runs
+	assert.False(t, rec.Record(httptest.NewRequest("GET", "/abc", nil), "abc"))
+	assert.Equal(t, uint64(1), rec.Dropped())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// a cancelled recorder still writes what is buffered
+	rec.Run(ctx)
+	require.Len(t, rec.clicks, 0)
+}
+
+func TestRecorder_NilRecordsNothing(t *testing.T) {
+	var rec *Recorder
+	assert.False(t, rec.Record(httptest.NewRequest("GET", "/abc", nil), "abc"))
+	assert.Equal(t, uint64(0), rec.Dropped())
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+// Scopes an API key can be granted, admin grants every other scope on the links of every tenant
+const (
+	ScopeCreate    = "create"
+	ScopeReadStats = "read-stats"
+	ScopeDelete    = "delete"
+	ScopeAdmin     = "admin"
+)
+
+// Scopes are the scopes an API key can be created with
+var Scopes = map[string]bool{
+	ScopeCreate:    true,
+	ScopeReadStats: true,
+	ScopeDelete:    true,
+	ScopeAdmin:     true,
+}
+
+// Admin is the caller holding the admin token
+var Admin = &Principal{KeyID: "admin", Scopes: []string{ScopeAdmin}}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	KeyID  string
+	Tenant string
+	Scopes []string
+}
+
+// Has reports whether p was granted scope
+func (p *Principal) Has(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Owns reports whether p may manage the links of owner
+func (p *Principal) Owns(owner string) bool {
+	return p.Has(ScopeAdmin) || (p.Tenant != "" && p.Tenant == owner)
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the caller of the request of ctx, nil when it was not authenticated
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Tenant is the tenant of the caller of ctx, empty without one
+func Tenant(ctx context.Context) string {
+	if p := FromContext(ctx); p != nil {
+		return p.Tenant
+	}
+	return ""
+}
+
+// CanManage reports whether the caller of ctx may manage a link of owner, unauthenticated callers never can
+func CanManage(ctx context.Context, owner string) bool {
+	p := FromContext(ctx)
+	return p != nil && p.Owns(owner)
+}
+
+// NewKey creates an API key of tenant and returns it with the token handed to the client,
+// `{id}.{secret}`. The token can not be recovered from the key.
+func NewKey(tenant string, scopes []string, now time.Time) (repository.APIKey, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return repository.APIKey{}, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return repository.APIKey{}, "", err
+	}
+
+	key := repository.APIKey{
+		ID:        id,
+		Tenant:    tenant,
+		Hash:      hash(secret),
+		Scopes:    scopes,
+		CreatedAt: now.Unix(),
+	}
+	return key, id + "." + secret, nil
+}
+
+// ParseToken splits a token into the id of its key and its secret
+func ParseToken(token string) (string, string, bool) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Verify reports whether secret is the secret of key
+func Verify(key *repository.APIKey, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(key.Hash)) == 1
+}
+
+// secrets are random, a fast hash is enough to keep them out of storage
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewKey_Verify(t *testing.T) {
+	key, token, err := NewKey("acme", []string{ScopeCreate}, time.Unix(1619766384, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", key.Tenant)
+	assert.Equal(t, int64(1619766384), key.CreatedAt)
+	assert.False(t, strings.Contains(key.Hash, token))
+
+	id, secret, ok := ParseToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, key.ID, id)
+	assert.True(t, Verify(&key, secret))
+	assert.False(t, Verify(&key, secret+"0"))
+}
+
+func TestParseToken(t *testing.T) {
+	for _, token := range []string{"", "abc", ".secret", "abc."} {
+		_, _, ok := ParseToken(token)
+		assert.False(t, ok, token)
+	}
+}
+
+func TestPrincipal_Scopes(t *testing.T) {
+	creator := &Principal{KeyID: "k1", Tenant: "acme", Scopes: []string{ScopeCreate}}
+	assert.True(t, creator.Has(ScopeCreate))
+	assert.False(t, creator.Has(ScopeDelete))
+	assert.True(t, Admin.Has(ScopeDelete))
+
+	assert.True(t, creator.Owns("acme"))
+	assert.False(t, creator.Owns("globex"))
+	// links created before tenants belong to nobody but the admin
+	assert.False(t, (&Principal{KeyID: "k2"}).Owns(""))
+	assert.True(t, Admin.Owns(""))
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromContext(ctx))
+	assert.Equal(t, "", Tenant(ctx))
+	assert.False(t, CanManage(ctx, ""))
+
+	ctx = NewContext(ctx, &Principal{KeyID: "k1", Tenant: "acme"})
+	assert.Equal(t, "acme", Tenant(ctx))
+	assert.True(t, CanManage(ctx, "acme"))
+}
@@ -0,0 +1,309 @@
+package blacklist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+const (
+	SourceConfig  = "config"
+	SourceFile    = "file"
+	SourceRuntime = "runtime"
+)
+
+var (
+	consoleLog zerolog.Logger
+
+	ErrPatternNotFound   = errors.New("pattern not found")
+	ErrPatternFromFile   = errors.New("pattern is loaded from the blacklist file, edit the file to remove it")
+	ErrPatternFromConfig = errors.New("pattern is set in the config, edit the config to remove it")
+	ErrInvalidPattern    = errors.New("invalid blacklist pattern")
+)
+
+// Pattern is a compiled blacklist entry with the place it was loaded from
+type Pattern struct {
+	Pattern string `json:"pattern"`
+	Source  string `json:"source"`
+	regex   *regexp.Regexp
+}
+
+// Blacklist holds the regular expressions destination urls are checked against.
+// A nil *Blacklist matches nothing.
+type Blacklist struct {
+	mu       sync.RWMutex
+	patterns []Pattern
+	file     string
+	modTime  time.Time
+	store    repository.PatternRepository
+}
+
+// New loads the config patterns, the blacklist file and the patterns added at runtime.
+// Runtime patterns are shared through store, without a store they only live in this process.
+func New(config Config, store repository.PatternRepository) (*Blacklist, error) {
+	list := &Blacklist{file: config.File, store: store}
+	for _, expr := range config.Patterns {
+		if err := list.add(expr, SourceConfig); err != nil {
+			return nil, err
+		}
+	}
+	if list.file != "" {
+		if err := list.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	if list.store != nil {
+		if err := list.Sync(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Match returns the first pattern the url matches
+func (b *Blacklist) Match(url string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, p := range b.patterns {
+		if p.regex.MatchString(url) {
+			return p.Pattern, true
+		}
+	}
+	return "", false
+}
+
+// List returns a copy of every loaded pattern
+func (b *Blacklist) List() []Pattern {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	patterns := make([]Pattern, len(b.patterns))
+	copy(patterns, b.patterns)
+	return patterns
+}
+
+// Add compiles expr, stores it and adds it to the blacklist, adding a known pattern again is a no-op
+func (b *Blacklist) Add(ctx context.Context, expr string) error {
+	if _, err := regexp.Compile(expr); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidPattern, expr, err)
+	}
+	if b.store != nil {
+		if err := b.store.AddPattern(ctx, expr); err != nil {
+			return err
+		}
+	}
+	return b.add(expr, SourceRuntime)
+}
+
+// Remove drops a pattern added at runtime from the store and from the blacklist
+func (b *Blacklist) Remove(ctx context.Context, expr string) error {
+	b.mu.RLock()
+	source := ""
+	for _, p := range b.patterns {
+		if p.Pattern == expr {
+			source = p.Source
+			break
+		}
+	}
+	b.mu.RUnlock()
+
+	switch source {
+	case SourceFile:
+		return ErrPatternFromFile
+	case SourceConfig:
+		return ErrPatternFromConfig
+	}
+
+	if b.store != nil {
+		// another instance may have removed it already, only the local copy is left then
+		err := b.store.RemovePattern(ctx, expr)
+		if err == repository.ErrNotFound && source == "" {
+			return ErrPatternNotFound
+		} else if err != nil && err != repository.ErrNotFound {
+			return err
+		}
+	} else if source == "" {
+		return ErrPatternNotFound
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, p := range b.patterns {
+		if p.Pattern == expr && p.Source == SourceRuntime {
+			b.patterns = append(b.patterns[:i], b.patterns[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Sync replaces the runtime patterns with the ones in the store, so the patterns
+// added or removed by other instances apply here too
+func (b *Blacklist) Sync(ctx context.Context) error {
+	stored, err := b.store.ListPatterns(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make([]Pattern, 0, len(stored))
+	for _, expr := range stored {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			consoleLog.Warn().Msgf("Skip invalid stored blacklist pattern: %q, err: %v", expr, err)
+			continue
+		}
+		loaded = append(loaded, Pattern{Pattern: expr, Source: SourceRuntime, regex: regex})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	patterns := make([]Pattern, 0, len(b.patterns)+len(loaded))
+	known := make(map[string]bool, len(b.patterns))
+	for _, p := range b.patterns {
+		if p.Source != SourceRuntime {
+			patterns = append(patterns, p)
+			known[p.Pattern] = true
+		}
+	}
+	for _, p := range loaded {
+		if !known[p.Pattern] {
+			patterns = append(patterns, p)
+			known[p.Pattern] = true
+		}
+	}
+	b.patterns = patterns
+	return nil
+}
+
+// Reload replaces the patterns loaded from the blacklist file. The file holds one
+// pattern per line, blank lines and lines starting with # are ignored.
+func (b *Blacklist) Reload() error {
+	info, err := os.Stat(b.file)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := readFile(b.file)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	patterns := make([]Pattern, 0, len(b.patterns)+len(loaded))
+	for _, p := range b.patterns {
+		if p.Source != SourceFile {
+			patterns = append(patterns, p)
+		}
+	}
+	b.patterns = append(patterns, loaded...)
+	b.modTime = info.ModTime()
+	return nil
+}
+
+// Watch polls the blacklist file and the store every interval, until ctx is done.
+// The file is reloaded when it changed, the runtime patterns are synced with the store.
+func (b *Blacklist) Watch(ctx context.Context, interval time.Duration) {
+	if (b.file == "" && b.store == nil) || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if b.store != nil {
+				// keep the previous runtime patterns while the store is unavailable
+				if err := b.Sync(ctx); err != nil {
+					consoleLog.Warn().Msgf("Unexpected error to sync blacklist patterns, err: %v", err)
+				}
+			}
+			if b.file != "" {
+				b.watchFile()
+			}
+		}
+	}
+}
+
+// watchFile reloads the blacklist file when it changed since the last load
+func (b *Blacklist) watchFile() {
+	info, err := os.Stat(b.file)
+	if err != nil {
+		consoleLog.Warn().Msgf("Unexpected error to stat blacklist file: %v, err: %v", b.file, err)
+		return
+	}
+
+	b.mu.RLock()
+	changed := !info.ModTime().Equal(b.modTime)
+	b.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	// keep the previous patterns when the new file is broken
+	if err := b.Reload(); err != nil {
+		consoleLog.Warn().Msgf("Unexpected error to reload blacklist file: %v, err: %v", b.file, err)
+		return
+	}
+	consoleLog.Info().Msgf("Reloaded blacklist file: %v", b.file)
+}
+
+func (b *Blacklist) add(expr string, source string) error {
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidPattern, expr, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, p := range b.patterns {
+		if p.Pattern == expr {
+			return nil
+		}
+	}
+	b.patterns = append(b.patterns, Pattern{Pattern: expr, Source: source, regex: regex})
+	return nil
+}
+
+func readFile(path string) ([]Pattern, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []Pattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		regex, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blacklist pattern %q in %v: %v", line, path, err)
+		}
+		patterns = append(patterns, Pattern{Pattern: line, Source: SourceFile, regex: regex})
+	}
+	return patterns, scanner.Err()
+}
@@ -0,0 +1,115 @@
+package blacklist
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+func TestBlacklist_ConfigAndRuntimePatterns(t *testing.T) {
+	list, err := New(Config{Patterns: []string{`(?i)malware\.example`}}, nil)
+	require.NoError(t, err)
+
+	pattern, matched := list.Match("https://MALWARE.example/path")
+	assert.True(t, matched)
+	assert.Equal(t, `(?i)malware\.example`, pattern)
+
+	_, matched = list.Match("https://www.speedtest.net")
+	assert.False(t, matched)
+
+	ctx := context.Background()
+	assert.True(t, errors.Is(list.Add(ctx, `(`), ErrInvalidPattern))
+	require.NoError(t, list.Add(ctx, `speedtest\.net`))
+	_, matched = list.Match("https://www.speedtest.net")
+	assert.True(t, matched)
+
+	require.NoError(t, list.Remove(ctx, `speedtest\.net`))
+	_, matched = list.Match("https://www.speedtest.net")
+	assert.False(t, matched)
+	assert.Equal(t, ErrPatternNotFound, list.Remove(ctx, `speedtest\.net`))
+	assert.Equal(t, ErrPatternFromConfig, list.Remove(ctx, `(?i)malware\.example`))
+}
+
+func TestBlacklist_StoredPatterns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	store := mockrepository.NewMockPatternRepository(ctrl)
+	store.EXPECT().ListPatterns(gomock.Any()).Return([]string{`spam\.example`, `(`}, nil)
+	list, err := New(Config{}, store)
+	require.NoError(t, err)
+	_, matched := list.Match("http://spam.example")
+	assert.True(t, matched)
+
+	// the store is written before the pattern applies
+	store.EXPECT().AddPattern(gomock.Any(), `speedtest\.net`).Return(errors.New("redis error"))
+	assert.Error(t, list.Add(ctx, `speedtest\.net`))
+	_, matched = list.Match("https://www.speedtest.net")
+	assert.False(t, matched)
+
+	store.EXPECT().AddPattern(gomock.Any(), `speedtest\.net`).Return(nil)
+	require.NoError(t, list.Add(ctx, `speedtest\.net`))
+	_, matched = list.Match("https://www.speedtest.net")
+	assert.True(t, matched)
+
+	// another instance removed spam.example and added phishing.example
+	store.EXPECT().ListPatterns(gomock.Any()).Return([]string{`phishing\.example`, `speedtest\.net`}, nil)
+	require.NoError(t, list.Sync(ctx))
+	_, matched = list.Match("http://spam.example")
+	assert.False(t, matched)
+	_, matched = list.Match("http://phishing.example")
+	assert.True(t, matched)
+
+	store.EXPECT().RemovePattern(gomock.Any(), `phishing\.example`).Return(repository.ErrNotFound)
+	require.NoError(t, list.Remove(ctx, `phishing\.example`))
+	_, matched = list.Match("http://phishing.example")
+	assert.False(t, matched)
+
+	store.EXPECT().RemovePattern(gomock.Any(), `unknown`).Return(repository.ErrNotFound)
+	assert.Equal(t, ErrPatternNotFound, list.Remove(ctx, `unknown`))
+}
+
+func TestBlacklist_NilMatchesNothing(t *testing.T) {
+	var list *Blacklist
+	_, matched := list.Match("https://www.speedtest.net")
+	assert.False(t, matched)
+}
+
+func TestBlacklist_WatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blacklist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "blacklist.txt")
+	require.NoError(t, ioutil.WriteFile(file, []byte("# comment\n\nphishing\\.example\n"), 0644))
+
+	list, err := New(Config{File: file}, nil)
+	require.NoError(t, err)
+	_, matched := list.Match("http://phishing.example")
+	assert.True(t, matched)
+	assert.Equal(t, ErrPatternFromFile, list.Remove(context.Background(), `phishing\.example`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go list.Watch(ctx, 10*time.Millisecond)
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("spam\\.example\n"), 0644))
+	require.NoError(t, os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	assert.Eventually(t, func() bool {
+		_, matched := list.Match("http://spam.example")
+		return matched
+	}, time.Second, 10*time.Millisecond)
+	_, matched = list.Match("http://phishing.example")
+	assert.False(t, matched)
+}
@@ -0,0 +1,9 @@
+package blacklist
+
+import "time"
+
+type Config struct {
+	Patterns       []string
+	File           string
+	ReloadInterval time.Duration
+}
@@ -0,0 +1,82 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver reads the address of the client of a request. Requests coming from a trusted proxy are
+// resolved from X-Forwarded-For, read from the right: every proxy appends the address it got the request
+// from, so the first entry not written by a trusted proxy is the client. Entries left of it are written
+// by the client and never trusted. A nil Resolver trusts no proxy.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// New returns a Resolver trusting the proxies in cidrs, single addresses are accepted too
+func New(cidrs []string) (*Resolver, error) {
+	resolver := &Resolver{}
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			resolver.trusted = append(resolver.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+		}
+		resolver.trusted = append(resolver.trusted, network)
+	}
+	return resolver, nil
+}
+
+// IP returns the address of the client of r
+func (resolver *Resolver) IP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if resolver == nil || !resolver.isTrusted(ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		// a broken entry can not be told apart from a forged one, the last trusted hop is kept
+		if net.ParseIP(hop) == nil {
+			return ip
+		}
+		ip = hop
+		if !resolver.isTrusted(hop) {
+			return ip
+		}
+	}
+	return ip
+}
+
+func (resolver *Resolver) isTrusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range resolver.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package clientip
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolver_IP(t *testing.T) {
+	resolver, err := New([]string{"10.0.0.0/8", "2001:db8::1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "203.0.113.7"},
+		{"untrusted peer", "198.51.100.1:1234", []string{"203.0.113.7"}, "198.51.100.1"},
+		{"one proxy", "10.0.0.1:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		// the client wrote the leftmost entry, the proxy appended the address it saw
+		{"forged entry", "10.0.0.1:1234", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"proxy chain", "10.0.0.1:1234", []string{"1.2.3.4, 203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"repeated header", "10.0.0.1:1234", []string{"1.2.3.4", "203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"ipv6 proxy", "[2001:db8::1]:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"only proxies", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"broken entry", "10.0.0.1:1234", []string{"1.2.3.4, not-an-ip, 10.0.0.2"}, "10.0.0.2"},
+		{"no header", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		r.RemoteAddr = c.remote
+		for _, value := range c.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		assert.Equal(t, c.want, resolver.IP(r), c.name)
+	}
+
+	// without trusted proxies the header is ignored
+	var none *Resolver
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "10.0.0.1", none.IP(r))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = New([]string{"proxy.internal"})
+	assert.Error(t, err)
+}
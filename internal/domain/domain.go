@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// separator joins a namespace and a code into a stored code, routes never match it inside a code
+const separator = "/"
+
+var (
+	namespacePattern = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+	redirectStatuses = map[int]bool{
+		http.StatusMovedPermanently:  true,
+		http.StatusFound:             true,
+		http.StatusTemporaryRedirect: true,
+		http.StatusPermanentRedirect: true,
+	}
+)
+
+// Config of a branded domain. The codes of a domain live in its namespace so the same code can exist on
+// several domains, the domain without namespace keeps the codes created before domains were configured.
+type Config struct {
+	Host string
+	// Scheme of its short urls, https when empty
+	Scheme    string
+	Namespace string
+	// Default serves the hosts that are not configured and the links created without domain
+	Default bool
+	// Expire is the default expiry in days of the links created on the domain, 0 keeps the global default
+	Expire int64
+	// Status is the redirect status of the links created on the domain without one. Keys of list items
+	// keep their case when the configuration is read, so fields of Config are single words.
+	Status int
+}
+
+// Domain short links are served from
+type Domain struct {
+	Host      string
+	Scheme    string
+	Namespace string
+	// Expiry is the default expiry of its links, 0 keeps the global default
+	Expiry         time.Duration
+	RedirectStatus int
+}
+
+// Code is the stored code of code on d
+func (d *Domain) Code(code string) string {
+	return Join(d.Namespace, code)
+}
+
+// ShortURL is the url of code on d
+func (d *Domain) ShortURL(code string) string {
+	return d.Scheme + "://" + d.Host + "/" + code
+}
+
+// Join is the stored code of code in namespace, codes without namespace are stored as they are
+func Join(namespace string, code string) string {
+	if namespace == "" {
+		return code
+	}
+	return namespace + separator + code
+}
+
+// Split returns the namespace of a stored code and its code on its domain
+func Split(stored string) (string, string) {
+	if i := strings.Index(stored, separator); i >= 0 {
+		return stored[:i], stored[i+len(separator):]
+	}
+	return "", stored
+}
+
+// Domains are the configured domains. Without any, or without a default one, unknown hosts are served
+// like before domains existed: over http and without namespace. A nil *Domains has no domain configured.
+type Domains struct {
+	byHost      map[string]*Domain
+	byNamespace map[string]*Domain
+	fallback    *Domain
+}
+
+// New checks configs, hosts and namespaces must be unique and at most one domain is the default
+func New(configs []Config) (*Domains, error) {
+	domains := &Domains{
+		byHost:      make(map[string]*Domain, len(configs)),
+		byNamespace: make(map[string]*Domain, len(configs)),
+	}
+	for _, config := range configs {
+		host := normalize(config.Host)
+		switch {
+		case host == "":
+			return nil, fmt.Errorf("domain without host")
+		case domains.byHost[host] != nil:
+			return nil, fmt.Errorf("domain %v configured twice", host)
+		case config.Namespace != "" && !namespacePattern.MatchString(config.Namespace):
+			return nil, fmt.Errorf("namespace of domain %v must match %v", host, namespacePattern)
+		case domains.byNamespace[config.Namespace] != nil:
+			return nil, fmt.Errorf("namespace %q of domain %v used twice", config.Namespace, host)
+		case config.Default && domains.fallback != nil:
+			return nil, fmt.Errorf("domains %v and %v are both the default", domains.fallback.Host, host)
+		case config.Status != 0 && !redirectStatuses[config.Status]:
+			return nil, fmt.Errorf("redirect status of domain %v must be 301, 302, 307 or 308", host)
+		}
+
+		scheme := config.Scheme
+		if scheme == "" {
+			scheme = "https"
+		} else if scheme != "http" && scheme != "https" {
+			return nil, fmt.Errorf("scheme of domain %v must be http or https", host)
+		}
+
+		domain := &Domain{
+			Host:           host,
+			Scheme:         scheme,
+			Namespace:      config.Namespace,
+			Expiry:         time.Duration(config.Expire) * 24 * time.Hour,
+			RedirectStatus: config.Status,
+		}
+		domains.byHost[host] = domain
+		domains.byNamespace[domain.Namespace] = domain
+		if config.Default {
+			domains.fallback = domain
+		}
+	}
+	return domains, nil
+}
+
+// Hosts are the hosts of the configured domains
+func (d *Domains) Hosts() []string {
+	if d == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(d.byHost))
+	for host := range d.byHost {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// Named returns the configured domain of host, nil when it is not configured
+func (d *Domains) Named(host string) *Domain {
+	if d == nil {
+		return nil
+	}
+	return d.byHost[normalize(host)]
+}
+
+// ForHost returns the domain serving requests to host
+func (d *Domains) ForHost(host string) *Domain {
+	if domain := d.Named(host); domain != nil {
+		return domain
+	}
+	if d != nil && d.fallback != nil {
+		return d.fallback
+	}
+	return &Domain{Host: host, Scheme: "http"}
+}
+
+// Select returns the configured domain named by param, the domain serving host when param is empty.
+// It returns nil when param names a domain that is not configured.
+func (d *Domains) Select(param string, host string) *Domain {
+	if param == "" {
+		return d.ForHost(host)
+	}
+	return d.Named(param)
+}
+
+// ForNamespace returns the domain of the codes in namespace, host serves a namespace without domain
+func (d *Domains) ForNamespace(namespace string, host string) *Domain {
+	if d != nil {
+		if domain := d.byNamespace[namespace]; domain != nil {
+			return domain
+		}
+	}
+	// links created before domains, or on a domain removed from the configuration, stay under host
+	return &Domain{Host: host, Scheme: "http", Namespace: namespace}
+}
+
+// normalize lowercases host and strips its port
+func normalize(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
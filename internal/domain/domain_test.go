@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNew_Invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		configs []Config
+	}{
+		{"no host", []Config{{Namespace: "go"}}},
+		{"host twice", []Config{{Host: "go.example.com", Namespace: "a"}, {Host: "GO.example.com:443", Namespace: "b"}}},
+		{"namespace twice", []Config{{Host: "a.example.com", Namespace: "go"}, {Host: "b.example.com", Namespace: "go"}}},
+		{"namespace with separator", []Config{{Host: "go.example.com", Namespace: "go/x"}}},
+		{"two defaults", []Config{{Host: "a.example.com", Default: true}, {Host: "b.example.com", Namespace: "b", Default: true}}},
+		{"scheme", []Config{{Host: "go.example.com", Scheme: "ftp"}}},
+		{"redirect status", []Config{{Host: "go.example.com", Status: 303}}},
+	}
+
+	for _, c := range cases {
+		_, err := New(c.configs)
+		assert.Error(t, err, c.name)
+	}
+}
+
+func TestForHost(t *testing.T) {
+	domains, err := New([]Config{
+		{Host: "go.example.com", Namespace: "go", Expire: 7, Status: 301},
+		{Host: "example.com", Scheme: "http"},
+	})
+	assert.NoError(t, err)
+
+	branded := domains.ForHost("Go.Example.com:8080")
+	assert.Equal(t, "go/abc", branded.Code("abc"))
+	assert.Equal(t, "https://go.example.com/abc", branded.ShortURL("abc"))
+	assert.Equal(t, 7*24*time.Hour, branded.Expiry)
+	assert.Equal(t, 301, branded.RedirectStatus)
+	assert.Equal(t, "abc", domains.ForHost("example.com").Code("abc"))
+
+	// without a default domain unknown hosts are served like before domains
+	unknown := domains.ForHost("localhost:3000")
+	assert.Equal(t, "abc", unknown.Code("abc"))
+	assert.Equal(t, "http://localhost:3000/abc", unknown.ShortURL("abc"))
+	assert.Nil(t, domains.Named("localhost"))
+}
+
+func TestForHost_Default(t *testing.T) {
+	domains, err := New([]Config{{Host: "go.example.com", Namespace: "go", Default: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, "go/abc", domains.ForHost("localhost:3000").Code("abc"))
+
+	var none *Domains
+	assert.Equal(t, "abc", none.ForHost("localhost").Code("abc"))
+	assert.Nil(t, none.Named("localhost"))
+}
+
+func TestSelect(t *testing.T) {
+	domains, err := New([]Config{{Host: "go.example.com", Namespace: "go"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "go/abc", domains.Select("go.example.com", "localhost:3000").Code("abc"))
+	assert.Equal(t, "go/abc", domains.Select("", "go.example.com").Code("abc"))
+	assert.Equal(t, "abc", domains.Select("", "localhost:3000").Code("abc"))
+	assert.Nil(t, domains.Select("localhost", "go.example.com"))
+}
+
+func TestSplit(t *testing.T) {
+	namespace, code := Split("go/abc")
+	assert.Equal(t, "go", namespace)
+	assert.Equal(t, "abc", code)
+
+	namespace, code = Split("abc")
+	assert.Equal(t, "", namespace)
+	assert.Equal(t, "abc", code)
+}
@@ -0,0 +1,17 @@
+package encode
+
+const (
+	StrategyHash    = "hash"
+	StrategyCounter = "counter"
+	StrategyRandom  = "random"
+
+	AlphabetBase58 = "base58"
+	AlphabetBase62 = "base62"
+)
+
+type Config struct {
+	Strategy   string
+	Alphabet   string
+	Length     int
+	MaxRetries int
+}
@@ -0,0 +1,170 @@
+package encode
+
+//go:generate mockgen -source=./generator.go -destination=./mocks/generator.go
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"url-shortener/internal/repository"
+)
+
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	defaultLength     = 7
+	defaultMaxRetries = 5
+)
+
+// Generator produces candidate short codes. The caller reserves the candidate and asks
+// again with the next attempt number when the code is already taken.
+type Generator interface {
+	Generate(ctx context.Context, input string, attempt int) (string, error)
+	// GenerateBatch returns the first candidate of every input, codes[i] is the one of inputs[i]
+	GenerateBatch(ctx context.Context, inputs []string) ([]string, error)
+}
+
+// NewGenerator builds the generator selected by config.Strategy, hash is the default
+func NewGenerator(config Config, counter repository.Counter, counterKey string) (Generator, error) {
+	alphabet, err := alphabetOf(config.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Strategy {
+	case "", StrategyHash:
+		return &HashGenerator{}, nil
+	case StrategyCounter:
+		return &CounterGenerator{Counter: counter, Key: counterKey, Alphabet: alphabet}, nil
+	case StrategyRandom:
+		length := config.Length
+		if length <= 0 {
+			length = defaultLength
+		}
+		return &RandomGenerator{Length: length, Alphabet: alphabet}, nil
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", config.Strategy)
+	}
+}
+
+// Retries returns how many collisions a generator may run into before giving up
+func (config Config) Retries() int {
+	if config.MaxRetries <= 0 {
+		return defaultMaxRetries
+	}
+	return config.MaxRetries
+}
+
+// HashGenerator derives the code from the SHA-256 of the input, the same input gives the same code.
+// Collisions are resolved by salting the input with the attempt number.
+type HashGenerator struct{}
+
+func (g *HashGenerator) Generate(ctx context.Context, input string, attempt int) (string, error) {
+	if attempt > 0 {
+		input = fmt.Sprintf("%v#%d", input, attempt)
+	}
+	urlHashBytes := Sha256Of(input)
+	generatedNumber := new(big.Int).SetBytes(urlHashBytes).Uint64()
+	return Base58Encoded([]byte(fmt.Sprintf("%d", generatedNumber))), nil
+}
+
+func (g *HashGenerator) GenerateBatch(ctx context.Context, inputs []string) ([]string, error) {
+	return generateEach(ctx, g, inputs)
+}
+
+// CounterGenerator encodes the next value of a storage counter, codes stay short and never repeat
+type CounterGenerator struct {
+	Counter  repository.Counter
+	Key      string
+	Alphabet string
+}
+
+func (g *CounterGenerator) Generate(ctx context.Context, input string, attempt int) (string, error) {
+	next, err := g.Counter.Incr(ctx, g.Key)
+	if err != nil {
+		return "", err
+	}
+	return EncodeUint(uint64(next), g.Alphabet), nil
+}
+
+// GenerateBatch reserves a range of len(inputs) values with a single increment
+func (g *CounterGenerator) GenerateBatch(ctx context.Context, inputs []string) ([]string, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	last, err := g.Counter.IncrBy(ctx, g.Key, int64(len(inputs)))
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, len(inputs))
+	first := last - int64(len(inputs)) + 1
+	for i := range codes {
+		codes[i] = EncodeUint(uint64(first+int64(i)), g.Alphabet)
+	}
+	return codes, nil
+}
+
+// RandomGenerator picks Length characters of Alphabet from crypto/rand
+type RandomGenerator struct {
+	Length   int
+	Alphabet string
+}
+
+func (g *RandomGenerator) Generate(ctx context.Context, input string, attempt int) (string, error) {
+	max := big.NewInt(int64(len(g.Alphabet)))
+	code := make([]byte, g.Length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = g.Alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func (g *RandomGenerator) GenerateBatch(ctx context.Context, inputs []string) ([]string, error) {
+	return generateEach(ctx, g, inputs)
+}
+
+// generateEach asks g for the first candidate of every input in turn
+func generateEach(ctx context.Context, g Generator, inputs []string) ([]string, error) {
+	codes := make([]string, len(inputs))
+	for i, input := range inputs {
+		code, err := g.Generate(ctx, input, 0)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// EncodeUint writes n in the positional numeral system made of alphabet
+func EncodeUint(n uint64, alphabet string) string {
+	base := uint64(len(alphabet))
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	var encoded []byte
+	for n > 0 {
+		encoded = append([]byte{alphabet[n%base]}, encoded...)
+		n /= base
+	}
+	return string(encoded)
+}
+
+func alphabetOf(name string) (string, error) {
+	switch name {
+	case "", AlphabetBase58:
+		return base58Alphabet, nil
+	case AlphabetBase62:
+		return base62Alphabet, nil
+	default:
+		return "", fmt.Errorf("unknown short code alphabet %q", name)
+	}
+}
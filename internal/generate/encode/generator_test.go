@@ -0,0 +1,79 @@
+package encode
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+func TestNewGenerator_UnknownStrategy(t *testing.T) {
+	_, err := NewGenerator(Config{Strategy: "uuid"}, nil, "")
+	assert.Error(t, err)
+
+	_, err = NewGenerator(Config{Alphabet: "base64"}, nil, "")
+	assert.Error(t, err)
+}
+
+func TestHashGenerator_SaltsRetries(t *testing.T) {
+	generator, err := NewGenerator(Config{}, nil, "")
+	require.NoError(t, err)
+
+	first, _ := generator.Generate(context.Background(), "https://www.speedtest.net", 0)
+	again, _ := generator.Generate(context.Background(), "https://www.speedtest.net", 0)
+	retry, _ := generator.Generate(context.Background(), "https://www.speedtest.net", 1)
+	assert.Equal(t, first, again)
+	assert.NotEqual(t, first, retry)
+}
+
+func TestCounterGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCounter := mockrepository.NewMockCounter(ctrl)
+	mockCounter.EXPECT().Incr(gomock.Any(), "shortner:counter").Return(int64(3843), nil)
+	mockCounter.EXPECT().Incr(gomock.Any(), "shortner:counter").Return(int64(0), errors.New("redis error"))
+
+	generator, err := NewGenerator(Config{Strategy: StrategyCounter, Alphabet: AlphabetBase62}, mockCounter, "shortner:counter")
+	require.NoError(t, err)
+
+	code, err := generator.Generate(context.Background(), "https://www.speedtest.net", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "zz", code)
+
+	_, err = generator.Generate(context.Background(), "https://www.speedtest.net", 0)
+	assert.Error(t, err)
+}
+
+func TestCounterGenerator_Batch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCounter := mockrepository.NewMockCounter(ctrl)
+	mockCounter.EXPECT().IncrBy(gomock.Any(), "shortner:counter", int64(3)).Return(int64(3844), nil)
+
+	generator, err := NewGenerator(Config{Strategy: StrategyCounter, Alphabet: AlphabetBase62}, mockCounter, "shortner:counter")
+	require.NoError(t, err)
+
+	codes, err := generator.GenerateBatch(context.Background(), []string{"https://a.example", "https://b.example", "https://c.example"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zy", "zz", "100"}, codes)
+}
+
+func TestRandomGenerator(t *testing.T) {
+	generator, err := NewGenerator(Config{Strategy: StrategyRandom, Length: 9}, nil, "")
+	require.NoError(t, err)
+
+	code, err := generator.Generate(context.Background(), "https://www.speedtest.net", 0)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[1-9A-HJ-NP-Za-km-z]{9}$`, code)
+}
+
+func TestEncodeUint(t *testing.T) {
+	assert.Equal(t, "0", EncodeUint(0, base62Alphabet))
+	assert.Equal(t, "10", EncodeUint(62, base62Alphabet))
+	assert.Equal(t, "21", EncodeUint(58, base58Alphabet))
+}
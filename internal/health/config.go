@@ -0,0 +1,10 @@
+package health
+
+import "time"
+
+type Config struct {
+	// Timeout is how long a dependency check may take before it fails, multiplied by time.Second
+	Timeout time.Duration
+	// DrainDelay is how long readiness fails before the server stops accepting connections, multiplied by time.Second
+	DrainDelay time.Duration
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// ErrDraining fails readiness once the service shuts down
+var ErrDraining = errors.New("shutting down")
+
+// Check reports whether a dependency can be used, ctx is done once the check timed out
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Latency is how long the check took in milliseconds, the timeout when it did not answer
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the service with the result of every check
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Failing lists the names of the failed checks
+func (r Report) Failing() []string {
+	var names []string
+	for _, result := range r.Checks {
+		if result.Status != StatusOK {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// Checker runs the checks telling whether the service can serve. Checks run concurrently and each
+// is given up on after its timeout. Once draining, readiness fails whatever the checks say.
+type Checker struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	checks map[string]Check
+
+	draining int32
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		now:     time.Now,
+		checks:  map[string]Check{},
+	}
+}
+
+// Register adds the check of the dependency name, a check registered twice replaces the first one
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Drain fails readiness from now on so load balancers stop sending requests before the server shuts down
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+func (c *Checker) Draining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// Ready runs every check and reports the service ready when all of them passed and it is not draining.
+// Results are sorted by name.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.Unlock()
+
+	results := make([]Result, 0, len(checks)+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := c.run(ctx, name, check)
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	if c.Draining() {
+		results = append(results, Result{Name: "shutdown", Status: StatusFailing, Error: ErrDraining.Error()})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusOK, Checks: results}
+	if len(report.Failing()) > 0 {
+		report.Status = StatusFailing
+	}
+	return report
+}
+
+// run runs check within the timeout, a check that does not return in time fails with the context error
+func (c *Checker) run(ctx context.Context, name string, check Check) Result {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := c.now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:    name,
+		Status:  StatusOK,
+		Latency: float64(c.now().Sub(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
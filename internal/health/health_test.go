@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func ok(ctx context.Context) error {
+	return nil
+}
+
+func TestChecker_Ready(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("redis", ok)
+	checker.Register("config", ok)
+
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, "config", report.Checks[0].Name)
+	assert.Equal(t, "redis", report.Checks[1].Name)
+	assert.Equal(t, StatusOK, report.Checks[1].Status)
+	assert.Empty(t, report.Failing())
+}
+
+func TestChecker_FailingCheck(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("config", ok)
+	checker.Register("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, []string{"redis"}, report.Failing())
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	// the check ignores its context, the checker still gives up on it
+	checker.Register("redis", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Ready(context.Background())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	assert.GreaterOrEqual(t, report.Checks[0].Latency, float64(10))
+}
+
+func TestChecker_Drain(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("redis", ok)
+	assert.False(t, checker.Draining())
+
+	checker.Drain()
+	assert.True(t, checker.Draining())
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, []string{"shutdown"}, report.Failing())
+	assert.Equal(t, ErrDraining.Error(), report.Checks[1].Error)
+}
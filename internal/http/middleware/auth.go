@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/repository"
+)
+
+const bearerPrefix = "Bearer "
+
+// Authenticate only lets requests through that carry `Authorization: Bearer <token>` with the admin token
+// or the token of an API key in keys, and puts the caller in the request context for auth.FromContext.
+// An empty admin token never matches.
+func Authenticate(keys repository.KeyRepository, adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticate(keys, adminToken, r)
+			if err != nil {
+				msg := fmt.Sprintf("storage error (%v)", err)
+				log.Error().Msg(msg)
+				rest.WriteResponse(w, http.StatusBadRequest, &rest.ErrorResponse{
+					Error: rest.Response{
+						Code:    rest.ErrCodeRedis["Code"].(int),
+						Message: rest.ErrCodeRedis["Message"].(string),
+					},
+				})
+				return
+			}
+			if principal == nil {
+				rest.WriteResponse(w, http.StatusUnauthorized, &rest.ErrorResponse{
+					Error: rest.Response{
+						Code:    rest.ErrCodeUnauthorized["Code"].(int),
+						Message: rest.ErrCodeUnauthorized["Message"].(string),
+					},
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireScope answers 403 to callers without scope, it runs after Authenticate
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal := auth.FromContext(r.Context()); principal == nil || !principal.Has(scope) {
+				rest.WriteResponse(w, http.StatusForbidden, &rest.ErrorResponse{
+					Error: rest.Response{
+						Code:    rest.ErrCodeForbidden["Code"].(int),
+						Message: fmt.Sprintf(rest.ErrCodeForbidden["Message"].(string), "the API key lacks the "+scope+" scope"),
+					},
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authenticate returns the caller of r, nil when its token is missing or unknown
+func authenticate(keys repository.KeyRepository, adminToken string, r *http.Request) (*auth.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return nil, nil
+	}
+	token := strings.TrimPrefix(header, bearerPrefix)
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+		return auth.Admin, nil
+	}
+
+	id, secret, ok := auth.ParseToken(token)
+	if !ok {
+		return nil, nil
+	}
+	key, err := keys.GetKey(r.Context(), id)
+	if err == repository.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !auth.Verify(key, secret) {
+		return nil, nil
+	}
+	return &auth.Principal{KeyID: key.ID, Tenant: key.Tenant, Scopes: key.Scopes}, nil
+}
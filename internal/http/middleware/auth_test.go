@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, token, err := auth.NewKey("acme", []string{auth.ScopeCreate}, time.Now())
+	assert.NoError(t, err)
+	keys := mockrepository.NewMockKeyRepository(ctrl)
+	keys.EXPECT().GetKey(gomock.Any(), key.ID).Return(&key, nil).Times(2)
+	keys.EXPECT().GetKey(gomock.Any(), "unknown").Return(nil, repository.ErrNotFound)
+	keys.EXPECT().GetKey(gomock.Any(), "broken").Return(nil, errors.New("redis error"))
+
+	var tenant string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = auth.Tenant(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name   string
+		header string
+		status int
+		tenant string
+	}{
+		{"api key", "Bearer " + token, http.StatusOK, "acme"},
+		{"admin token", "Bearer secret", http.StatusOK, ""},
+		{"wrong secret", "Bearer " + key.ID + ".other", http.StatusUnauthorized, ""},
+		{"unknown key", "Bearer unknown.other", http.StatusUnauthorized, ""},
+		{"storage error", "Bearer broken.other", http.StatusBadRequest, ""},
+		{"missing header", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, c := range cases {
+		tenant = ""
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/generate", nil)
+		if c.header != "" {
+			r.Header.Set("Authorization", c.header)
+		}
+		Authenticate(keys, "secret")(next).ServeHTTP(w, r)
+		assert.Equal(t, c.status, w.Code, c.name)
+		assert.Equal(t, c.tenant, tenant, c.name)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		status    int
+	}{
+		{"granted", &auth.Principal{KeyID: "k1", Scopes: []string{auth.ScopeDelete}}, http.StatusOK},
+		{"admin", auth.Admin, http.StatusOK},
+		{"other scope", &auth.Principal{KeyID: "k1", Scopes: []string{auth.ScopeCreate}}, http.StatusForbidden},
+		{"unauthenticated", nil, http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/abc", nil)
+		if c.principal != nil {
+			r = r.WithContext(auth.NewContext(r.Context(), c.principal))
+		}
+		RequireScope(auth.ScopeDelete)(next).ServeHTTP(w, r)
+		assert.Equal(t, c.status, w.Code, c.name)
+	}
+}
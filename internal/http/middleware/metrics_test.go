@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/{code}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://www.speedtest.net", http.StatusFound)
+	}).Methods(http.MethodGet)
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/urls/{code}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	}).Methods(http.MethodDelete)
+
+	requests := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/{code}", http.MethodGet, "302"))
+	observed := sampleCount(t, metrics.HTTPDuration.WithLabelValues("/{code}", http.MethodGet, "302"))
+	deletes := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/admin/urls/{code}", http.MethodDelete, "200"))
+
+	for _, code := range []string{"abc", "def"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+code, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/admin/urls/abc", nil))
+
+	// links share the series of their route
+	assert.Equal(t, requests+2, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/{code}", http.MethodGet, "302")))
+	assert.Equal(t, observed+2, sampleCount(t, metrics.HTTPDuration.WithLabelValues("/{code}", http.MethodGet, "302")))
+	assert.Equal(t, deletes+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/admin/urls/{code}", http.MethodDelete, "200")))
+}
+
+// sampleCount is the number of observations of a histogram series
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	assert.NoError(t, observer.(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/clientip"
+	"url-shortener/internal/http/rest"
+	"url-shortener/internal/ratelimit"
+)
+
+// RateLimit answers 429 once the client of a request, as told by client, went over the limit of limiter
+// on route. Every answer carries the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// blocked ones Retry-After too. A nil limiter lets every request through.
+func RateLimit(limiter *ratelimit.SlidingWindow, route string, client func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := limiter.Allow(r.Context(), route+":"+client(r))
+			if decision.Limit > 0 {
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+				w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+			}
+			if !decision.Allowed {
+				retry := seconds(decision.RetryAfter)
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				rest.WriteResponse(w, http.StatusTooManyRequests, &rest.ErrorResponse{
+					Error: rest.Response{
+						Code:    rest.ErrCodeRateLimited["Code"].(int),
+						Message: fmt.Sprintf(rest.ErrCodeRateLimited["Message"].(string), retry),
+					},
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Client tells clients apart by their API key once authenticated, by their address otherwise
+func Client(resolver *clientip.Resolver) func(r *http.Request) string {
+	clientIP := ClientIP(resolver)
+	return func(r *http.Request) string {
+		if principal := auth.FromContext(r.Context()); principal != nil {
+			return "key:" + principal.KeyID
+		}
+		return clientIP(r)
+	}
+}
+
+// ClientIP tells clients apart by their address, X-Forwarded-For is only read from the proxies resolver trusts
+func ClientIP(resolver *clientip.Resolver) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return "ip:" + resolver.IP(r)
+	}
+}
+
+// seconds rounds d up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/clientip"
+	"url-shortener/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RateLimit(ratelimit.NewSlidingWindow(nil, ratelimit.Config{Limit: 1, Window: time.Minute}), "generate", ClientIP(nil))(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":1017`)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// another client has its own counter
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/generate", nil)
+	r.RemoteAddr = "10.0.0.2:1234"
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RateLimit(ratelimit.NewSlidingWindow(nil, ratelimit.Config{}), "generate", ClientIP(nil))(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	assert.Equal(t, "ip:10.0.0.1", ClientIP(nil)(r))
+	proxies, err := clientip.New([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	assert.Equal(t, "ip:203.0.113.7", ClientIP(proxies)(r))
+
+	// the leftmost entry is written by the client
+	r.Header.Set("X-Forwarded-For", "198.51.100.9, 203.0.113.7")
+	assert.Equal(t, "ip:203.0.113.7", ClientIP(proxies)(r))
+}
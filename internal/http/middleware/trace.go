@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"url-shortener/internal/tracing"
+)
+
+// Trace serves every request of the routes of a router in a server span of provider, it is installed with
+// Router.Use so spans are named after the route. A request carrying a traceparent header continues the
+// trace of its caller. Handlers pass the request context on so storage calls are children of the span.
+func Trace(provider trace.TracerProvider) func(http.Handler) http.Handler {
+	tracer := provider.Tracer(tracing.Instrumentation)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+
+			ctx, span := tracer.Start(tracing.Extract(r.Context(), r.Header), r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method), semconv.HTTPRouteKey.String(route), semconv.HTTPTargetKey.String(r.URL.Path)))
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				tracing.Fail(span, fmt.Errorf("%v %v", recorder.status, http.StatusText(recorder.status)))
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/tracing"
+)
+
+func TestTrace(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	router := mux.NewRouter()
+	router.Use(Trace(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))))
+	router.HandleFunc("/{code}", func(w http.ResponseWriter, r *http.Request) {
+		// storage calls are children of the request
+		_, span := tracing.Start(r.Context(), "HGETALL", trace.SpanKindClient)
+		span.End()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}).Methods(http.MethodGet)
+
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	storage, server := ended[0], ended[1]
+	assert.Equal(t, "GET /{code}", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.ElementsMatch(t, []interface{}{
+		semconv.HTTPMethodKey.String(http.MethodGet),
+		semconv.HTTPRouteKey.String("/{code}"),
+		semconv.HTTPTargetKey.String("/abc"),
+		semconv.HTTPStatusCodeKey.Int(http.StatusServiceUnavailable),
+	}, server.Attributes())
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	assert.Equal(t, "HGETALL", storage.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), storage.Parent().SpanID())
+}
@@ -31,6 +31,74 @@ var (
 		"Code":    1006,
 		"Message": "Data not found",
 	}
+	ErrCodeConflict = map[string]interface{}{
+		"Code":    1007,
+		"Message": "short_code %v is already taken",
+	}
+	ErrCodeHitsExceeded = map[string]interface{}{
+		"Code":    1008,
+		"Message": "url has reached its maximum number of hits",
+	}
+	ErrCodeUnauthorized = map[string]interface{}{
+		"Code":    1009,
+		"Message": "Invalid or missing API key",
+	}
+	ErrCodeUrlDeleted = map[string]interface{}{
+		"Code":    1010,
+		"Message": "url has been deleted",
+	}
+	ErrCodeGenerate = map[string]interface{}{
+		"Code":    1011,
+		"Message": "Unable to generate a unique short code, please retry",
+	}
+	ErrCodeReplayConflict = map[string]interface{}{
+		"Code":    1012,
+		"Message": "%v already created a link with different parameters",
+	}
+	ErrCodeVersion = map[string]interface{}{
+		"Code":    1013,
+		"Message": "url has been modified since version %v",
+	}
+	ErrCodeDateMax = map[string]interface{}{
+		"Code":    1014,
+		"Message": "Invalid expire_date value. The expire_date must be at most %v from now",
+	}
+	ErrCodePassword = map[string]interface{}{
+		"Code":    1015,
+		"Message": "Invalid or missing password",
+	}
+	ErrCodeTooManyAttempts = map[string]interface{}{
+		"Code":    1016,
+		"Message": "Too many wrong passwords, retry in %v seconds",
+	}
+	ErrCodeRateLimited = map[string]interface{}{
+		"Code":    1017,
+		"Message": "Too many requests, retry in %v seconds",
+	}
+	ErrCodeForbidden = map[string]interface{}{
+		"Code":    1018,
+		"Message": "Forbidden, %v",
+	}
+	ErrCodeURLScheme = map[string]interface{}{
+		"Code":    1019,
+		"Message": "Invalid url, the %v scheme is not allowed",
+	}
+	ErrCodeURLPrivate = map[string]interface{}{
+		"Code":    1020,
+		"Message": "Invalid url, %v is not a public host",
+	}
+	ErrCodeURLLoop = map[string]interface{}{
+		"Code":    1021,
+		"Message": "Invalid url, %v already serves short links",
+	}
+	ErrCodeURLFlagged = map[string]interface{}{
+		"Code":    1022,
+		"Message": "Unsafe url, the destination is reported as %v",
+	}
+	ErrCodeNotReady = map[string]interface{}{
+		"Code":    1023,
+		"Message": "Service not ready, failing %v",
+	}
 )
 
 type ErrorResponse struct {
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	LinksCreated.WithLabelValues("generate").Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, w.Body.String(), "# TYPE url_shortener_links_created_total counter\n")
+	assert.Contains(t, w.Body.String(), `url_shortener_links_created_total{endpoint="generate"}`)
+	// the runtime metrics are served with those of the service
+	assert.Contains(t, w.Body.String(), "go_goroutines ")
+}
+
+func TestRegisterCache(t *testing.T) {
+	hits, misses := uint64(3), uint64(1)
+	RegisterCache(func() (uint64, uint64) {
+		return hits, misses
+	})
+
+	expected := `# HELP url_shortener_cache_hit_ratio Share of the lookups of links served from the cache.
+# TYPE url_shortener_cache_hit_ratio gauge
+url_shortener_cache_hit_ratio 0.75
+# HELP url_shortener_cache_hits_total Lookups of links served from the cache.
+# TYPE url_shortener_cache_hits_total counter
+url_shortener_cache_hits_total 3
+# HELP url_shortener_cache_misses_total Lookups of links that missed the cache.
+# TYPE url_shortener_cache_misses_total counter
+url_shortener_cache_misses_total 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(Default, strings.NewReader(expected),
+		"url_shortener_cache_hit_ratio", "url_shortener_cache_hits_total", "url_shortener_cache_misses_total"))
+
+	// the cache is registered once
+	assert.Panics(t, func() {
+		RegisterCache(func() (uint64, uint64) { return 0, 0 })
+	})
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// HTTPRequests counts requests per route template, method and status
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "url_shortener_http_requests_total",
+		Help: "Requests served, per route, method and status.",
+	}, []string{"route", "method", "status"})
+	// HTTPDuration is the latency of requests per route template, method and status
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "url_shortener_http_request_duration_seconds",
+		Help:    "Latency of the requests served, per route, method and status.",
+		Buckets: DefaultBuckets,
+	}, []string{"route", "method", "status"})
+
+	// RedisDuration is the latency of Redis operations per command
+	RedisDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "url_shortener_redis_operation_duration_seconds",
+		Help:    "Latency of the Redis operations, per command.",
+		Buckets: DefaultBuckets,
+	}, []string{"operation"})
+	// RedisErrors counts failed Redis operations per command
+	RedisErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "url_shortener_redis_operation_errors_total",
+		Help: "Redis operations that failed, per command.",
+	}, []string{"operation"})
+
+	// Redirects counts visitors sent to the destination of a link, per redirect status
+	Redirects = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "url_shortener_redirects_total",
+		Help: "Visitors redirected to the destination of a link, per redirect status.",
+	}, []string{"status"})
+	// LinksCreated counts new links per endpoint, generate or batch
+	LinksCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "url_shortener_links_created_total",
+		Help: "Links created, per endpoint.",
+	}, []string{"endpoint"})
+)
+
+// RegisterCache registers the hits and misses of the link cache, read from stats, with their ratio
+func RegisterCache(stats func() (hits uint64, misses uint64)) {
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "url_shortener_cache_hits_total",
+		Help: "Lookups of links served from the cache.",
+	}, func() float64 {
+		hits, _ := stats()
+		return float64(hits)
+	})
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "url_shortener_cache_misses_total",
+		Help: "Lookups of links that missed the cache.",
+	}, func() float64 {
+		_, misses := stats()
+		return float64(misses)
+	})
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "url_shortener_cache_hit_ratio",
+		Help: "Share of the lookups of links served from the cache.",
+	}, func() float64 {
+		hits, misses := stats()
+		if hits+misses == 0 {
+			return 0
+		}
+		return float64(hits) / float64(hits+misses)
+	})
+}
@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	qr "github.com/skip2/go-qrcode"
+	"strings"
+)
+
+// Level is the error correction level of a QR code, the share of the symbol that can be damaged and still be read
+type Level int
+
+const (
+	// LevelL restores about 7% of the codewords
+	LevelL Level = iota
+	// LevelM restores about 15% of the codewords
+	LevelM
+	// LevelQ restores about 25% of the codewords
+	LevelQ
+	// LevelH restores about 30% of the codewords
+	LevelH
+)
+
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// recoveryLevels are the levels of the encoder
+var recoveryLevels = [...]qr.RecoveryLevel{LevelL: qr.Low, LevelM: qr.Medium, LevelQ: qr.High, LevelH: qr.Highest}
+
+// ParseLevel reads a level from its letter, L, M, Q or H in any case
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToUpper(value) {
+	case "L":
+		return LevelL, nil
+	case "M":
+		return LevelM, nil
+	case "Q":
+		return LevelQ, nil
+	case "H":
+		return LevelH, nil
+	}
+	return 0, fmt.Errorf("unknown error correction level %q", value)
+}
+
+func (l Level) String() string {
+	return [...]string{"L", "M", "Q", "H"}[l]
+}
+
+// Code is a QR code symbol, a square of Size modules without quiet zone
+type Code struct {
+	Version int
+	Size    int
+	Level   Level
+
+	modules [][]bool
+}
+
+// Encode encodes data into the smallest version holding it at level, the quiet zone is left to the renderers
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < LevelL || level > LevelH {
+		return nil, fmt.Errorf("unknown error correction level %v", int(level))
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no data for a QR code")
+	}
+
+	// with data, the encoder only fails when no version holds it
+	symbol, err := qr.New(string(data), recoveryLevels[level])
+	if err != nil {
+		return nil, ErrTooLong
+	}
+	symbol.DisableBorder = true
+
+	// the bitmap is built once, the encoder pads its data again on every call
+	modules := symbol.Bitmap()
+	return &Code{
+		Version: symbol.VersionNumber,
+		Size:    len(modules),
+		Level:   level,
+		modules: modules,
+	}, nil
+}
+
+// Black reports whether the module at x, y is dark, modules outside the symbol are light
+func (c *Code) Black(x int, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
@@ -0,0 +1,96 @@
+package qrcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestCapacity(t *testing.T) {
+	code, err := Encode(bytes.Repeat([]byte("a"), 17), LevelL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, code.Version)
+	code, err = Encode(bytes.Repeat([]byte("a"), 18), LevelL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, code.Version)
+
+	code, err = Encode(bytes.Repeat([]byte("a"), 2953), LevelL)
+	require.NoError(t, err)
+	assert.Equal(t, 40, code.Version)
+	_, err = Encode(bytes.Repeat([]byte("a"), 2954), LevelL)
+	assert.Equal(t, ErrTooLong, err)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("q")
+	require.NoError(t, err)
+	assert.Equal(t, LevelQ, level)
+	assert.Equal(t, "Q", level.String())
+
+	_, err = ParseLevel("X")
+	assert.Error(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	for level := LevelL; level <= LevelH; level++ {
+		code, err := Encode([]byte("https://sho.rt/abc"), level)
+		require.NoError(t, err)
+		assert.Equal(t, level, code.Level)
+		assert.Equal(t, 17+4*code.Version, code.Size)
+
+		// the finder patterns sit in three corners, without quiet zone
+		for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+			x, y := corner[0], corner[1]
+			assert.True(t, code.Black(x, y), "%v at %v", corner, level)
+			assert.True(t, code.Black(x+6, y+6), "%v at %v", corner, level)
+			assert.False(t, code.Black(x+1, y+1), "%v at %v", corner, level)
+			assert.True(t, code.Black(x+3, y+3), "%v at %v", corner, level)
+		}
+	}
+	code, err := Encode([]byte("https://sho.rt/abc"), LevelM)
+	require.NoError(t, err)
+	assert.False(t, code.Black(-1, 0))
+	assert.False(t, code.Black(code.Size, 0))
+
+	_, err = Encode(nil, LevelM)
+	assert.Error(t, err)
+	_, err = Encode([]byte("https://sho.rt/abc"), Level(4))
+	assert.Error(t, err)
+}
+
+func TestPNG(t *testing.T) {
+	code, err := Encode([]byte("https://sho.rt/abc"), LevelM)
+	require.NoError(t, err)
+
+	data, err := code.PNG(256, QuietZone)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+
+	// 29 modules of 8 pixels with 12 pixels left around them
+	scale := 256 / code.Modules(QuietZone)
+	offset := (256-scale*code.Modules(QuietZone))/2 + QuietZone*scale
+	black := color.GrayModel.Convert(color.Black)
+	assert.Equal(t, black, color.GrayModel.Convert(img.At(offset, offset)))
+	assert.Equal(t, black, color.GrayModel.Convert(img.At(offset+7*scale-1, offset+7*scale-1)))
+	assert.NotEqual(t, black, color.GrayModel.Convert(img.At(offset-1, offset-1)))
+	assert.NotEqual(t, black, color.GrayModel.Convert(img.At(offset+scale, offset+scale)))
+
+	_, err = code.PNG(code.Modules(QuietZone)-1, QuietZone)
+	assert.Error(t, err)
+}
+
+func TestSVG(t *testing.T) {
+	code, err := Encode([]byte("https://sho.rt/abc"), LevelM)
+	require.NoError(t, err)
+
+	svg := string(code.SVG(300, 2))
+	assert.Contains(t, svg, `width="300" height="300" viewBox="0 0 29 29"`)
+	// the top row of the first finder is one run
+	assert.Contains(t, svg, `M2,2h7v1h-7z`)
+}
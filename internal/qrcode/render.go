@@ -0,0 +1,83 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const (
+	// QuietZone is the margin in modules scanners expect around a symbol
+	QuietZone = 4
+	// DefaultSize is the size in pixels of the images rendered when none is asked for
+	DefaultSize = 256
+)
+
+var palette = color.Palette{color.White, color.Black}
+
+// Modules is the width of the symbol with a margin of light modules on every side
+func (c *Code) Modules(margin int) int {
+	return c.Size + 2*margin
+}
+
+// PNG renders the symbol with margin modules around it into a square image of size pixels. Modules are
+// whole pixels, the pixels they leave are spread around the symbol. size must hold a pixel per module.
+func (c *Code) PNG(size int, margin int) ([]byte, error) {
+	modules := c.Modules(margin)
+	if size < modules {
+		return nil, fmt.Errorf("image of %v pixels too small for %v modules", size, modules)
+	}
+	scale := size / modules
+	offset := (size-scale*modules)/2 + margin*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := img.Pix[(offset+y*scale+dy)*img.Stride:]
+				for dx := 0; dx < scale; dx++ {
+					row[offset+x*scale+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol with margin modules around it as a square of size pixels, runs of dark modules
+// are drawn as one rectangle
+func (c *Code) SVG(size int, margin int) []byte {
+	modules := c.Modules(margin)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#FFFFFF"/>`+"\n", modules, modules)
+	buf.WriteString(`<path fill="#000000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			run := 1
+			for x+run < c.Size && c.modules[y][x+run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d,%dh%dv1h-%dz", x+margin, y+margin, run, run)
+			x += run - 1
+		}
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "time"
+
+type Config struct {
+	Limit  int
+	Window time.Duration
+}
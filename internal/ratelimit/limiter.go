@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// sweepSize is the number of tracked keys above which expired windows are dropped
+const sweepSize = 10000
+
+// Limiter counts hits per key in fixed windows and blocks a key once it reached Limit hits
+// in the current window. Counters live in Store, shared by every instance, the in-memory
+// Fallback takes over while Store fails. A nil *Limiter never blocks.
+type Limiter struct {
+	Store    Store
+	Fallback Store
+	config   Config
+	now      func() time.Time
+}
+
+// NewLimiter keeps counters in store, in process memory when store is nil
+func NewLimiter(store Store, config Config) *Limiter {
+	fallback := NewMemoryStore()
+	if store == nil {
+		store = fallback
+	}
+	return &Limiter{
+		Store:    store,
+		Fallback: fallback,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// Blocked reports whether key used up its hits and how long until its window resets
+func (l *Limiter) Blocked(ctx context.Context, key string) (bool, time.Duration) {
+	if l == nil || l.config.Limit <= 0 || l.config.Window <= 0 {
+		return false, 0
+	}
+
+	now := l.now()
+	start := now.Truncate(l.config.Window)
+	hits, err := l.Store.Hits(ctx, key, start)
+	if err != nil {
+		log.Warn().Msgf("rate limit store error, counting in memory (%v)", err)
+		hits, _ = l.Fallback.Hits(ctx, key, start)
+	}
+	if hits < int64(l.config.Limit) {
+		return false, 0
+	}
+	return true, start.Add(l.config.Window).Sub(now)
+}
+
+// Hit counts one hit for key
+func (l *Limiter) Hit(ctx context.Context, key string) {
+	if l == nil || l.config.Limit <= 0 || l.config.Window <= 0 {
+		return
+	}
+
+	start := l.now().Truncate(l.config.Window)
+	if _, _, err := l.Store.IncrWindow(ctx, key, start, l.config.Window); err != nil {
+		log.Warn().Msgf("rate limit store error, counting in memory (%v)", err)
+		_, _, _ = l.Fallback.IncrWindow(ctx, key, start, l.config.Window)
+	}
+}
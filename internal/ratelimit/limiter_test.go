@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_BlocksUntilWindowEnds(t *testing.T) {
+	now := time.Unix(1619766360, 0)
+	limiter := NewLimiter(nil, Config{Limit: 2, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Hit(context.Background(), "abc")
+	blocked, _ := limiter.Blocked(context.Background(), "abc")
+	assert.False(t, blocked)
+
+	now = now.Add(24 * time.Second)
+	limiter.Hit(context.Background(), "abc")
+	blocked, retry := limiter.Blocked(context.Background(), "abc")
+	assert.True(t, blocked)
+	assert.Equal(t, 36*time.Second, retry)
+
+	// other keys are counted on their own
+	blocked, _ = limiter.Blocked(context.Background(), "def")
+	assert.False(t, blocked)
+
+	now = now.Add(36 * time.Second)
+	blocked, _ = limiter.Blocked(context.Background(), "abc")
+	assert.False(t, blocked)
+}
+
+func TestLimiter_FallsBackToMemory(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, Config{Limit: 1, Window: time.Minute})
+
+	limiter.Hit(context.Background(), "abc")
+	blocked, _ := limiter.Blocked(context.Background(), "abc")
+	assert.True(t, blocked)
+}
+
+func TestLimiter_NilNeverBlocks(t *testing.T) {
+	var limiter *Limiter
+	limiter.Hit(context.Background(), "abc")
+	blocked, _ := limiter.Blocked(context.Background(), "abc")
+	assert.False(t, blocked)
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps the hit counters of fixed windows
+type Store interface {
+	// IncrWindow counts a hit of key in the window starting at start and returns the hits of that window
+	// and of the window before it
+	IncrWindow(ctx context.Context, key string, start time.Time, window time.Duration) (int64, int64, error)
+	// Hits returns the hits of key in the window starting at start without counting one
+	Hits(ctx context.Context, key string, start time.Time) (int64, error)
+}
+
+// Decision is the outcome of counting one request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the current window ends
+	Reset time.Duration
+	// RetryAfter is how long a blocked client has to wait before its next request is allowed
+	RetryAfter time.Duration
+}
+
+// SlidingWindow allows Limit requests per key over any Window long period. The hits of the previous
+// fixed window are weighted by the part of it the sliding window still covers. Blocked requests are
+// counted too, a client has to slow down below the limit to get through again. Counters live in Store,
+// the in-memory Fallback takes over while Store fails. A nil *SlidingWindow allows everything.
+type SlidingWindow struct {
+	Store    Store
+	Fallback Store
+	config   Config
+	now      func() time.Time
+}
+
+// NewSlidingWindow keeps counters in store, in process memory when store is nil
+func NewSlidingWindow(store Store, config Config) *SlidingWindow {
+	fallback := NewMemoryStore()
+	if store == nil {
+		store = fallback
+	}
+	return &SlidingWindow{
+		Store:    store,
+		Fallback: fallback,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// Allow counts a request of key and decides whether it goes through
+func (l *SlidingWindow) Allow(ctx context.Context, key string) Decision {
+	if l == nil || l.config.Limit <= 0 || l.config.Window <= 0 {
+		return Decision{Allowed: true}
+	}
+
+	now := l.now()
+	window := l.config.Window
+	start := now.Truncate(window)
+	current, previous, err := l.Store.IncrWindow(ctx, key, start, window)
+	if err != nil {
+		log.Warn().Msgf("rate limit store error, counting in memory (%v)", err)
+		current, previous, _ = l.Fallback.IncrWindow(ctx, key, start, window)
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(previous)*weight + float64(current)
+	limit := float64(l.config.Limit)
+
+	decision := Decision{
+		Allowed:   estimated <= limit,
+		Limit:     l.config.Limit,
+		Remaining: int(math.Max(0, math.Floor(limit-estimated))),
+		Reset:     start.Add(window).Sub(now),
+	}
+	if !decision.Allowed {
+		decision.RetryAfter = retryAfter(current, previous, limit, elapsed, window)
+	}
+	return decision
+}
+
+// retryAfter is the time until one more request fits below limit when no other request comes in
+func retryAfter(current int64, previous int64, limit float64, elapsed time.Duration, window time.Duration) time.Duration {
+	var wait time.Duration
+	if float64(current)+1 > limit {
+		// the current window alone is full, wait for it to become the previous one and fade out
+		wait = window - elapsed + time.Duration(float64(window)*(1-(limit-1)/float64(current)))
+	} else {
+		wait = time.Duration(float64(window)*(1-(limit-1-float64(current))/float64(previous))) - elapsed
+	}
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait
+}
+
+type counters struct {
+	start    time.Time
+	current  int64
+	previous int64
+}
+
+// MemoryStore keeps window counters in process memory, each instance counts on its own
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counters
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counters{}}
+}
+
+func (m *MemoryStore) IncrWindow(ctx context.Context, key string, start time.Time, window time.Duration) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counters[key]
+	switch {
+	case !ok:
+		if len(m.counters) >= sweepSize {
+			m.sweep(start, window)
+		}
+		c = &counters{start: start}
+		m.counters[key] = c
+	case c.start.Equal(start.Add(-window)):
+		c.start, c.previous, c.current = start, c.current, 0
+	case !c.start.Equal(start):
+		c.start, c.previous, c.current = start, 0, 0
+	}
+	c.current++
+	return c.current, c.previous, nil
+}
+
+func (m *MemoryStore) Hits(ctx context.Context, key string, start time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counters[key]
+	if !ok || !c.start.Equal(start) {
+		return 0, nil
+	}
+	return c.current, nil
+}
+
+// sweep drops the counters no later window reads anymore
+func (m *MemoryStore) sweep(start time.Time, window time.Duration) {
+	for key, expired := range m.counters {
+		if expired.start.Before(start.Add(-window)) {
+			delete(m.counters, key)
+		}
+	}
+}
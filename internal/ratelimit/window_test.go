@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type failingStore struct{}
+
+func (failingStore) IncrWindow(ctx context.Context, key string, start time.Time, window time.Duration) (int64, int64, error) {
+	return 0, 0, errors.New("redis error")
+}
+
+func (failingStore) Hits(ctx context.Context, key string, start time.Time) (int64, error) {
+	return 0, errors.New("redis error")
+}
+
+func TestSlidingWindow_WeightsPreviousWindow(t *testing.T) {
+	now := time.Unix(1619766360, 0)
+	limiter := NewSlidingWindow(nil, Config{Limit: 4, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		assert.True(t, limiter.Allow(context.Background(), "abc").Allowed)
+	}
+	decision := limiter.Allow(context.Background(), "abc")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+	assert.Equal(t, time.Minute, decision.Reset)
+	// the 5 hits weigh 3 after 24s of the next window, leaving room for one more
+	assert.Equal(t, time.Minute+24*time.Second, decision.RetryAfter)
+
+	// halfway through the next window the 5 previous hits weigh 2.5
+	now = now.Add(90 * time.Second)
+	decision = limiter.Allow(context.Background(), "abc")
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+	assert.False(t, limiter.Allow(context.Background(), "abc").Allowed)
+
+	// other keys are counted on their own
+	assert.True(t, limiter.Allow(context.Background(), "def").Allowed)
+
+	// two windows later nothing is left
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, 3, limiter.Allow(context.Background(), "abc").Remaining)
+}
+
+func TestSlidingWindow_FallsBackToMemory(t *testing.T) {
+	limiter := NewSlidingWindow(failingStore{}, Config{Limit: 1, Window: time.Minute})
+
+	assert.True(t, limiter.Allow(context.Background(), "abc").Allowed)
+	assert.False(t, limiter.Allow(context.Background(), "abc").Allowed)
+}
+
+func TestSlidingWindow_NoLimit(t *testing.T) {
+	var limiter *SlidingWindow
+	assert.True(t, limiter.Allow(context.Background(), "abc").Allowed)
+
+	limiter = NewSlidingWindow(failingStore{}, Config{})
+	assert.Equal(t, Decision{Allowed: true}, limiter.Allow(context.Background(), "abc"))
+}
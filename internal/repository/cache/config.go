@@ -0,0 +1,11 @@
+package cache
+
+import "time"
+
+type Config struct {
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+	// WarmTimeout bounds how long Warm reads links, the most visited of those read by then are cached. 0 reads them all.
+	WarmTimeout time.Duration
+}
@@ -0,0 +1,45 @@
+package cache
+
+import "time"
+
+// Images is an LRU of rendered images such as QR codes, they never change for a key so writes need
+// no invalidation. A nil *Images caches nothing.
+type Images struct {
+	cache *lru
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewImages returns nil when config.Size is not positive, a TTL of zero keeps images until they are evicted
+func NewImages(config Config) *Images {
+	if config.Size <= 0 {
+		return nil
+	}
+	return &Images{
+		cache: newLRU(config.Size),
+		ttl:   config.TTL,
+		now:   time.Now,
+	}
+}
+
+func (i *Images) Get(key string) ([]byte, bool) {
+	if i == nil {
+		return nil, false
+	}
+	value, ok := i.cache.get(key, i.now())
+	if !ok {
+		return nil, false
+	}
+	return value.([]byte), true
+}
+
+func (i *Images) Add(key string, image []byte) {
+	if i == nil {
+		return
+	}
+	expires := time.Unix(1<<62, 0)
+	if i.ttl > 0 {
+		expires = i.now().Add(i.ttl)
+	}
+	i.cache.add(key, image, expires)
+}
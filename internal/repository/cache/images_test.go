@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestImages(t *testing.T) {
+	now := time.Unix(1619766384, 0)
+	images := NewImages(Config{Size: 1, TTL: time.Minute})
+	images.now = func() time.Time { return now }
+
+	images.Add("abc|png", []byte("png"))
+	image, ok := images.Get("abc|png")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("png"), image)
+
+	// the oldest image is evicted
+	images.Add("abc|svg", []byte("svg"))
+	_, ok = images.Get("abc|png")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	_, ok = images.Get("abc|svg")
+	assert.False(t, ok)
+}
+
+func TestImages_Disabled(t *testing.T) {
+	images := NewImages(Config{})
+	assert.Nil(t, images)
+
+	images.Add("abc|png", []byte("png"))
+	_, ok := images.Get("abc|png")
+	assert.False(t, ok)
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+// Stats reports how often the lookup path was served from memory
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// lookup is a cached Get result, err is ErrNotFound or ErrDeleted for negative entries
+type lookup struct {
+	link repository.Link
+	err  error
+}
+
+// Repository decorates a repository.LinkRepository with an in-process LRU in front of Get.
+// Unknown and deleted codes are cached for NegativeTTL so they do not reach the backend either.
+// Every write made through the repository drops the code from the cache, other instances see
+// the change once their entries expire. IncrementHits is the exception: hits change on every
+// visit, so a cached link may report a stale counter for up to TTL.
+type Repository struct {
+	// counters first to keep them 64-bit aligned for sync/atomic
+	hits   uint64
+	misses uint64
+	warm   int32
+
+	repository.LinkRepository
+
+	cache       *lru
+	ttl         time.Duration
+	negativeTTL time.Duration
+	warmTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewRepository(next repository.LinkRepository, config Config) *Repository {
+	return &Repository{
+		LinkRepository: next,
+		cache:          newLRU(config.Size),
+		ttl:            config.TTL,
+		negativeTTL:    config.NegativeTTL,
+		warmTimeout:    config.WarmTimeout,
+		now:            time.Now,
+	}
+}
+
+func (r *Repository) Get(ctx context.Context, code string) (*repository.Link, error) {
+	now := r.now()
+	if value, ok := r.cache.get(code, now); ok {
+		atomic.AddUint64(&r.hits, 1)
+		return value.(*lookup).result()
+	}
+	atomic.AddUint64(&r.misses, 1)
+
+	link, err := r.LinkRepository.Get(ctx, code)
+	switch err {
+	case nil:
+		if r.ttl > 0 {
+			r.cache.add(code, &lookup{link: *link}, now.Add(r.ttl))
+		}
+	case repository.ErrNotFound, repository.ErrDeleted:
+		if r.negativeTTL > 0 {
+			r.cache.add(code, &lookup{err: err}, now.Add(r.negativeTTL))
+		}
+	}
+	return link, err
+}
+
+func (r *Repository) Create(ctx context.Context, link repository.Link) (bool, error) {
+	defer r.Invalidate(link.Code)
+	return r.LinkRepository.Create(ctx, link)
+}
+
+func (r *Repository) CreateBatch(ctx context.Context, links []repository.Link) ([]bool, error) {
+	defer func() {
+		for _, link := range links {
+			r.Invalidate(link.Code)
+		}
+	}()
+	return r.LinkRepository.CreateBatch(ctx, links)
+}
+
+func (r *Repository) Update(ctx context.Context, link repository.Link) error {
+	defer r.Invalidate(link.Code)
+	return r.LinkRepository.Update(ctx, link)
+}
+
+func (r *Repository) Delete(ctx context.Context, code string) error {
+	defer r.Invalidate(code)
+	return r.LinkRepository.Delete(ctx, code)
+}
+
+func (r *Repository) Flag(ctx context.Context, code string, threat string) error {
+	defer r.Invalidate(code)
+	return r.LinkRepository.Flag(ctx, code, threat)
+}
+
+// Invalidate drops codes from the cache
+func (r *Repository) Invalidate(codes ...string) {
+	for _, code := range codes {
+		r.cache.remove(code)
+	}
+}
+
+// Stats returns the cache counters, a nil repository means caching is disabled
+func (r *Repository) Stats() Stats {
+	if r == nil {
+		return Stats{}
+	}
+	return Stats{
+		Hits:   atomic.LoadUint64(&r.hits),
+		Misses: atomic.LoadUint64(&r.misses),
+		Size:   r.cache.len(),
+	}
+}
+
+// ErrCold is returned by Ready until the cache was warmed
+var ErrCold = errors.New("cache is warming up")
+
+// warmPage is the number of links read per List call while warming
+const warmPage = 100
+
+// Warm fills the cache with the most visited links so the first redirects after a start do not all
+// reach the backend. Listing stops after the warm timeout, or when it fails, and the most visited of the
+// links read by then are cached, the cache reports warm either way. A link written while warming may be
+// cached at its previous state for up to TTL.
+func (r *Repository) Warm(ctx context.Context) (err error) {
+	defer atomic.StoreInt32(&r.warm, 1)
+	if r.ttl <= 0 || r.cache.size <= 0 {
+		return nil
+	}
+	if r.warmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.warmTimeout)
+		defer cancel()
+	}
+
+	// top keeps the r.cache.size most visited links, trimmed whenever it holds twice as many
+	var top []repository.Link
+	trim := func() {
+		sort.SliceStable(top, func(i, j int) bool { return top[i].Hits > top[j].Hits })
+		if len(top) > r.cache.size {
+			top = top[:r.cache.size]
+		}
+	}
+
+	filter := repository.ListFilter{Limit: warmPage}
+	for ctx.Err() == nil {
+		links, cursor, listErr := r.LinkRepository.List(ctx, filter)
+		if listErr != nil {
+			err = listErr
+			break
+		}
+		top = append(top, links...)
+		if len(top) >= 2*r.cache.size {
+			trim()
+		}
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+	trim()
+	// running out of time is not a failure, the links read by then are cached
+	if r.warmTimeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		err = nil
+	}
+
+	// least visited first, so the most visited links end up most recently used
+	expires := r.now().Add(r.ttl)
+	for i := len(top) - 1; i >= 0; i-- {
+		r.cache.add(top[i].Code, &lookup{link: top[i]}, expires)
+	}
+	return err
+}
+
+// Ready fails with ErrCold until Warm returned, it is the readiness check of the cache
+func (r *Repository) Ready(ctx context.Context) error {
+	if atomic.LoadInt32(&r.warm) == 0 {
+		return ErrCold
+	}
+	return nil
+}
+
+// result hands out a copy so callers can not modify the cached link
+func (e *lookup) result() (*repository.Link, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	link := e.link
+	return &link, nil
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"url-shortener/internal/repository"
+	mockrepository "url-shortener/internal/repository/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockRepository *mockrepository.MockLinkRepository
+	now            time.Time
+)
+
+func setUpRepositoryMocking(ctrl *gomock.Controller, size int) *Repository {
+	mockRepository = mockrepository.NewMockLinkRepository(ctrl)
+	now = time.Unix(1619766384, 0)
+
+	cached := NewRepository(mockRepository, Config{Size: size, TTL: time.Minute, NegativeTTL: time.Second})
+	cached.now = func() time.Time { return now }
+	return cached
+}
+
+func link(code string, fullURL string) *repository.Link {
+	return &repository.Link{Code: code, FullURL: fullURL}
+}
+
+func (s *TSuite) TestGet_ServedFromCache() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.speedtest.net"), nil).Times(1)
+
+	for i := 0; i < 3; i++ {
+		value, err := cached.Get(context.Background(), "code")
+		s.Require().NoError(err)
+		s.Assert().Equal("https://www.speedtest.net", value.FullURL)
+	}
+	s.Assert().Equal(Stats{Hits: 2, Misses: 1, Size: 1}, cached.Stats())
+}
+
+func (s *TSuite) TestGet_CopiesCachedLink() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.speedtest.net"), nil).Times(1)
+
+	value, _ := cached.Get(context.Background(), "code")
+	value.FullURL = "https://www.example.com"
+	value, _ = cached.Get(context.Background(), "code")
+	s.Assert().Equal("https://www.speedtest.net", value.FullURL)
+}
+
+func (s *TSuite) TestGet_ExpiresAfterTTL() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrNotFound).Times(2)
+	mockRepository.EXPECT().Get(gomock.Any(), "other").Return(link("other", "https://www.speedtest.net"), nil).Times(2)
+
+	_, err := cached.Get(context.Background(), "code")
+	s.Assert().Equal(repository.ErrNotFound, err)
+	_, _ = cached.Get(context.Background(), "other")
+	_, err = cached.Get(context.Background(), "code")
+	s.Assert().Equal(repository.ErrNotFound, err)
+	now = now.Add(2 * time.Second)
+	// the negative entry expired, the positive one is still cached
+	_, _ = cached.Get(context.Background(), "code")
+	_, _ = cached.Get(context.Background(), "other")
+	now = now.Add(time.Minute)
+	_, _ = cached.Get(context.Background(), "other")
+}
+
+func (s *TSuite) TestGet_ErrorIsNotCached() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, errors.New("redis error"))
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.speedtest.net"), nil)
+
+	_, err := cached.Get(context.Background(), "code")
+	s.Assert().Error(err)
+	value, _ := cached.Get(context.Background(), "code")
+	s.Assert().Equal("https://www.speedtest.net", value.FullURL)
+}
+
+func (s *TSuite) TestGet_EvictsLeastRecentlyUsed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 2)
+	mockRepository.EXPECT().Get(gomock.Any(), "a").Return(link("a", "1"), nil).Times(1)
+	mockRepository.EXPECT().Get(gomock.Any(), "b").Return(link("b", "2"), nil).Times(2)
+	mockRepository.EXPECT().Get(gomock.Any(), "c").Return(link("c", "3"), nil).Times(1)
+
+	_, _ = cached.Get(context.Background(), "a")
+	_, _ = cached.Get(context.Background(), "b")
+	_, _ = cached.Get(context.Background(), "a")
+	_, _ = cached.Get(context.Background(), "c")
+	// b was the least recently used entry
+	_, _ = cached.Get(context.Background(), "a")
+	_, _ = cached.Get(context.Background(), "b")
+}
+
+func (s *TSuite) TestDelete_Invalidates() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.speedtest.net"), nil)
+	mockRepository.EXPECT().Delete(gomock.Any(), "code").Return(nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(nil, repository.ErrDeleted)
+
+	_, _ = cached.Get(context.Background(), "code")
+	_ = cached.Delete(context.Background(), "code")
+	_, err := cached.Get(context.Background(), "code")
+	s.Assert().Equal(repository.ErrDeleted, err)
+}
+
+func (s *TSuite) TestGet_InvalidatedByUpdate() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 10)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.speedtest.net"), nil)
+	mockRepository.EXPECT().IncrementHits(gomock.Any(), "code").Return(int64(1), nil)
+	mockRepository.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "code").Return(link("code", "https://www.example.com"), nil)
+
+	value, _ := cached.Get(context.Background(), "code")
+	s.Assert().Equal("https://www.speedtest.net", value.FullURL)
+	// hits do not invalidate the cached link
+	_, _ = cached.IncrementHits(context.Background(), "code")
+	value, _ = cached.Get(context.Background(), "code")
+	s.Assert().Equal("https://www.speedtest.net", value.FullURL)
+
+	_ = cached.Update(context.Background(), *link("code", "https://www.example.com"))
+	value, _ = cached.Get(context.Background(), "code")
+	s.Assert().Equal("https://www.example.com", value.FullURL)
+}
+
+func (s *TSuite) TestWarm_CachesMostVisited() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 2)
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: warmPage}).
+		Return([]repository.Link{{Code: "a", Hits: 1}, {Code: "b", Hits: 5}}, "next", nil)
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: warmPage, Cursor: "next"}).
+		Return([]repository.Link{{Code: "c", Hits: 3}}, "", nil)
+	mockRepository.EXPECT().Get(gomock.Any(), "a").Return(link("a", "https://www.speedtest.net"), nil)
+
+	s.Assert().Equal(ErrCold, cached.Ready(context.Background()))
+	s.Require().NoError(cached.Warm(context.Background()))
+	s.Assert().NoError(cached.Ready(context.Background()))
+
+	_, _ = cached.Get(context.Background(), "b")
+	_, _ = cached.Get(context.Background(), "c")
+	_, _ = cached.Get(context.Background(), "a")
+	s.Assert().Equal(Stats{Hits: 2, Misses: 1, Size: 2}, cached.Stats())
+}
+
+func (s *TSuite) TestWarm_TimeoutCachesLinksRead() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 2)
+	cached.warmTimeout = 10 * time.Millisecond
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: warmPage}).
+		Return([]repository.Link{{Code: "a", Hits: 1}}, "next", nil)
+	// the second page does not answer before the timeout
+	mockRepository.EXPECT().List(gomock.Any(), repository.ListFilter{Limit: warmPage, Cursor: "next"}).
+		DoAndReturn(func(ctx context.Context, filter repository.ListFilter) ([]repository.Link, string, error) {
+			<-ctx.Done()
+			return nil, "", ctx.Err()
+		})
+
+	s.Require().NoError(cached.Warm(context.Background()))
+	s.Assert().NoError(cached.Ready(context.Background()))
+
+	_, _ = cached.Get(context.Background(), "a")
+	s.Assert().Equal(Stats{Hits: 1, Size: 1}, cached.Stats())
+}
+
+func (s *TSuite) TestWarm_FailedListIsWarm() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cached := setUpRepositoryMocking(ctrl, 2)
+	mockRepository.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, "", errors.New("unexpected"))
+
+	s.Assert().Error(cached.Warm(context.Background()))
+	s.Assert().NoError(cached.Ready(context.Background()))
+}
@@ -0,0 +1,12 @@
+package repository
+
+const (
+	BackendRedis = "redis"
+	BackendSQL   = "sql"
+)
+
+type Config struct {
+	Backend string
+	Driver  string
+	DSN     string
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+// putBatchRows caps the rows of one multi-row insert, SQLite allows 999 parameters by default
+const putBatchRows = 300
+
+// Put implements repository.IndexRepository with the link_index table
+func (r *LinkRepository) Put(ctx context.Context, key string, code string, expireAt int64) error {
+	defer r.span(ctx, "UPSERT").End()
+
+	_, err := r.db.ExecContext(ctx, r.rebind(`INSERT INTO link_index (name, code, expire_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET code = excluded.code, expire_at = excluded.expire_at`), key, code, expireAt)
+	return err
+}
+
+// PutBatch writes the entries with multi-row inserts in one transaction
+func (r *LinkRepository) PutBatch(ctx context.Context, entries []repository.IndexEntry) error {
+	defer r.span(ctx, "UPSERT").End()
+
+	// an upsert can not touch a row twice, the last entry of a key wins like with Put
+	last := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		last[entry.Key] = i
+	}
+	rows := make([]repository.IndexEntry, 0, len(last))
+	for i, entry := range entries {
+		if last[entry.Key] == i {
+			rows = append(rows, entry)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for start := 0; start < len(rows); start += putBatchRows {
+			end := start + putBatchRows
+			if end > len(rows) {
+				end = len(rows)
+			}
+
+			values := make([]string, 0, end-start)
+			args := make([]interface{}, 0, 3*(end-start))
+			for _, entry := range rows[start:end] {
+				values = append(values, "(?, ?, ?)")
+				args = append(args, entry.Key, entry.Code, entry.ExpireAt)
+			}
+			_, err := tx.ExecContext(ctx, r.rebind(`INSERT INTO link_index (name, code, expire_at) VALUES `+strings.Join(values, ", ")+`
+				ON CONFLICT (name) DO UPDATE SET code = excluded.code, expire_at = excluded.expire_at`), args...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Claim only replaces an expired entry
+func (r *LinkRepository) Claim(ctx context.Context, key string, code string, expireAt int64) (string, error) {
+	defer r.span(ctx, "UPSERT").End()
+
+	var kept string
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, r.rebind(`INSERT INTO link_index (name, code, expire_at) VALUES (?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET code = excluded.code, expire_at = excluded.expire_at
+			WHERE link_index.expire_at > 0 AND link_index.expire_at <= ?`), key, code, expireAt, r.now().Unix())
+		if err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx, r.rebind(`SELECT code FROM link_index WHERE name = ?`), key).Scan(&kept)
+	})
+	return kept, err
+}
+
+func (r *LinkRepository) Lookup(ctx context.Context, key string) (string, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	var code string
+	err := r.db.QueryRowContext(ctx, r.rebind(`SELECT code FROM link_index WHERE name = ? AND (expire_at = 0 OR expire_at > ?)`),
+		key, r.now().Unix()).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", repository.ErrNotFound
+	}
+	return code, err
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestIndex_PutReplaces() {
+	_, err := s.links.Lookup(context.Background(), "url:abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+
+	s.Require().NoError(s.links.Put(context.Background(), "url:abc", "first", 0))
+	s.Require().NoError(s.links.Put(context.Background(), "url:abc", "second", 0))
+
+	code, err := s.links.Lookup(context.Background(), "url:abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("second", code)
+}
+
+func (s *TSuite) TestIndex_ClaimKeepsLiveEntry() {
+	code, err := s.links.Claim(context.Background(), "idempotency:abc", "first", 4102444800)
+	s.Require().NoError(err)
+	s.Assert().Equal("first", code)
+
+	code, err = s.links.Claim(context.Background(), "idempotency:abc", "second", 4102444800)
+	s.Require().NoError(err)
+	s.Assert().Equal("first", code)
+}
+
+func (s *TSuite) TestIndex_ExpiredEntry() {
+	// now is 1619766384 in tests
+	s.Require().NoError(s.links.Put(context.Background(), "idempotency:abc", "first", 1619766000))
+
+	_, err := s.links.Lookup(context.Background(), "idempotency:abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+
+	code, err := s.links.Claim(context.Background(), "idempotency:abc", "second", 4102444800)
+	s.Require().NoError(err)
+	s.Assert().Equal("second", code)
+}
+
+func (s *TSuite) TestIndex_PutBatch() {
+	s.Require().NoError(s.links.Put(context.Background(), "url:abc", "old", 0))
+	s.Require().NoError(s.links.PutBatch(context.Background(), []repository.IndexEntry{
+		{Key: "url:abc", Code: "first"},
+		{Key: "url:def", Code: "second"},
+		{Key: "url:abc", Code: "third"},
+	}))
+
+	code, err := s.links.Lookup(context.Background(), "url:abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("third", code)
+
+	code, err = s.links.Lookup(context.Background(), "url:def")
+	s.Require().NoError(err)
+	s.Assert().Equal("second", code)
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+const selectKey = `SELECT id, tenant, hash, scopes, created_at FROM api_keys`
+
+// CreateKey implements repository.KeyRepository with the api_keys table
+func (r *LinkRepository) CreateKey(ctx context.Context, key repository.APIKey) error {
+	defer r.span(ctx, "INSERT").End()
+
+	_, err := r.db.ExecContext(ctx, r.rebind(`INSERT INTO api_keys (id, tenant, hash, scopes, created_at) VALUES (?, ?, ?, ?, ?)`),
+		key.ID, key.Tenant, key.Hash, strings.Join(key.Scopes, ","), key.CreatedAt)
+	return err
+}
+
+func (r *LinkRepository) GetKey(ctx context.Context, id string) (*repository.APIKey, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	key, err := scanKey(r.db.QueryRowContext(ctx, r.rebind(selectKey+` WHERE id = ?`), id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return key, err
+}
+
+func (r *LinkRepository) ListKeys(ctx context.Context) ([]repository.APIKey, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	rows, err := r.db.QueryContext(ctx, selectKey+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []repository.APIKey{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *LinkRepository) DeleteKey(ctx context.Context, id string) error {
+	defer r.span(ctx, "DELETE").End()
+
+	result, err := r.db.ExecContext(ctx, r.rebind(`DELETE FROM api_keys WHERE id = ?`), id)
+	return r.affectedOne(result, err)
+}
+
+func scanKey(row rowScanner) (*repository.APIKey, error) {
+	key := new(repository.APIKey)
+	var scopes string
+	if err := row.Scan(&key.ID, &key.Tenant, &key.Hash, &scopes, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	return key, nil
+}
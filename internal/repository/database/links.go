@@ -0,0 +1,345 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/tracing"
+)
+
+const selectLink = `SELECT code, full_url, expire_at, max_hits, hits, created_at, version, password_hash, redirect_status, owner, flagged, deleted_at FROM links`
+
+// LinkRepository stores links in a SQL database through database/sql. Queries are written
+// for PostgreSQL and SQLite, deleted links keep their row with deleted_at set as tombstone.
+type LinkRepository struct {
+	db     *sql.DB
+	driver string
+	now    func() time.Time
+}
+
+// Open connects to config.DSN with config.Driver and migrates the schema
+func Open(config repository.Config) (*LinkRepository, error) {
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return NewLinkRepository(db, config.Driver)
+}
+
+func NewLinkRepository(db *sql.DB, driver string) (*LinkRepository, error) {
+	if err := Migrate(db, driver); err != nil {
+		return nil, err
+	}
+	return &LinkRepository{
+		db:     db,
+		driver: driver,
+		now:    time.Now,
+	}, nil
+}
+
+func (r *LinkRepository) Close() error {
+	return r.db.Close()
+}
+
+// Ping checks the database answers, used by the readiness check
+func (r *LinkRepository) Ping(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "PING", trace.SpanKindClient,
+		semconv.DBSystemKey.String(system(r.driver)), semconv.DBOperationKey.String("PING"))
+	defer span.End()
+
+	err := r.db.PingContext(ctx)
+	tracing.Fail(span, err)
+	return err
+}
+
+// createLink takes a free code. A tombstone or an expired link, expire_at being before the last argument,
+// gives its code back with a version the old link never had.
+const createLink = `INSERT INTO links (code, full_url, expire_at, max_hits, hits, created_at, version, password_hash, redirect_status, owner, deleted_at)
+	VALUES (?, ?, ?, ?, 0, ?, 1, ?, ?, ?, 0)
+	ON CONFLICT (code) DO UPDATE SET full_url = excluded.full_url, expire_at = excluded.expire_at,
+		max_hits = excluded.max_hits, hits = 0, created_at = excluded.created_at, version = links.version + 1,
+		password_hash = excluded.password_hash, redirect_status = excluded.redirect_status,
+		owner = excluded.owner, flagged = '', deleted_at = 0
+	WHERE links.deleted_at > 0 OR (links.expire_at > 0 AND links.expire_at <= ?)`
+
+func (r *LinkRepository) Create(ctx context.Context, link repository.Link) (bool, error) {
+	defer r.span(ctx, "INSERT").End()
+
+	result, err := r.db.ExecContext(ctx, r.rebind(createLink), link.Code, link.FullURL, link.ExpireAt, link.MaxHits, link.CreatedAt, link.PasswordHash, link.RedirectStatus, link.Owner, r.now().Unix())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CreateBatch inserts every link in one transaction
+func (r *LinkRepository) CreateBatch(ctx context.Context, links []repository.Link) ([]bool, error) {
+	defer r.span(ctx, "INSERT").End()
+
+	created := make([]bool, len(links))
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, r.rebind(createLink))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		now := r.now().Unix()
+		for i, link := range links {
+			result, err := stmt.ExecContext(ctx, link.Code, link.FullURL, link.ExpireAt, link.MaxHits, link.CreatedAt, link.PasswordHash, link.RedirectStatus, link.Owner, now)
+			if err != nil {
+				return err
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			created[i] = affected > 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *LinkRepository) Update(ctx context.Context, link repository.Link) error {
+	defer r.span(ctx, "UPDATE").End()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.rebind(`UPDATE links SET full_url = ?, expire_at = ?, max_hits = ?, version = version + 1
+			WHERE code = ? AND deleted_at = 0 AND (? = 0 OR version = ?)`),
+			link.FullURL, link.ExpireAt, link.MaxHits, link.Code, link.Version, link.Version)
+		if err = r.affectedOne(result, err); err != repository.ErrNotFound {
+			return err
+		}
+
+		// tell a missing link from a deleted or modified one
+		var version, deletedAt int64
+		err = tx.QueryRowContext(ctx, r.rebind(`SELECT version, deleted_at FROM links WHERE code = ?`), link.Code).Scan(&version, &deletedAt)
+		switch {
+		case err == sql.ErrNoRows:
+			return repository.ErrNotFound
+		case err != nil:
+			return err
+		case deletedAt > 0:
+			return repository.ErrDeleted
+		}
+		return repository.ErrVersionMismatch
+	})
+}
+
+func (r *LinkRepository) Get(ctx context.Context, code string) (*repository.Link, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	link, deletedAt, err := scanLink(r.db.QueryRowContext(ctx, r.rebind(selectLink+` WHERE code = ?`), code))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if deletedAt > 0 {
+		return nil, repository.ErrDeleted
+	}
+	return link, nil
+}
+
+func (r *LinkRepository) Delete(ctx context.Context, code string) error {
+	defer r.span(ctx, "UPDATE").End()
+
+	result, err := r.db.ExecContext(ctx, r.rebind(`UPDATE links SET deleted_at = ? WHERE code = ? AND deleted_at = 0`), r.now().Unix(), code)
+	return r.affectedOne(result, err)
+}
+
+// List pages through links ordered by code, the cursor is the last code of the previous page
+func (r *LinkRepository) List(ctx context.Context, filter repository.ListFilter) ([]repository.Link, string, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	query := selectLink + ` WHERE deleted_at = 0 AND code > ?`
+	args := []interface{}{filter.Cursor}
+	if filter.CodePrefix != "" {
+		query += ` AND code LIKE ? ESCAPE '\'`
+		args = append(args, escapeLike(filter.CodePrefix)+"%")
+	}
+	if filter.Keyword != "" {
+		query += ` AND LOWER(full_url) LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(strings.ToLower(filter.Keyword))+"%")
+	}
+	if filter.Owner != "" {
+		query += ` AND owner = ?`
+		args = append(args, filter.Owner)
+	}
+	query += ` ORDER BY code LIMIT ` + strconv.Itoa(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	links := make([]repository.Link, 0, filter.Limit)
+	for rows.Next() {
+		link, _, err := scanLink(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		links = append(links, *link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(links) == filter.Limit {
+		next = links[len(links)-1].Code
+	}
+	return links, next, nil
+}
+
+func (r *LinkRepository) IncrementHits(ctx context.Context, code string) (int64, error) {
+	defer r.span(ctx, "UPDATE").End()
+
+	var hits int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.rebind(`UPDATE links SET hits = hits + 1 WHERE code = ? AND deleted_at = 0 AND (max_hits = 0 OR hits < max_hits)`), code)
+		err = r.affectedOne(result, err)
+		if err != nil && err != repository.ErrNotFound {
+			return err
+		}
+		// nothing was updated for an unknown code or a link whose quota is used up
+		var deletedAt, maxHits int64
+		if scanErr := tx.QueryRowContext(ctx, r.rebind(`SELECT hits, max_hits, deleted_at FROM links WHERE code = ?`), code).Scan(&hits, &maxHits, &deletedAt); scanErr == sql.ErrNoRows || deletedAt > 0 {
+			return repository.ErrNotFound
+		} else if scanErr != nil {
+			return scanErr
+		}
+		if err == repository.ErrNotFound {
+			return repository.ErrHitsExceeded
+		}
+		return nil
+	})
+	return hits, err
+}
+
+func (r *LinkRepository) Flag(ctx context.Context, code string, threat string) error {
+	defer r.span(ctx, "UPDATE").End()
+
+	result, err := r.db.ExecContext(ctx, r.rebind(`UPDATE links SET flagged = ? WHERE code = ? AND deleted_at = 0`), threat, code)
+	return r.affectedOne(result, err)
+}
+
+// Incr implements repository.Counter with the counters table
+func (r *LinkRepository) Incr(ctx context.Context, key string) (int64, error) {
+	return r.IncrBy(ctx, key, 1)
+}
+
+func (r *LinkRepository) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	defer r.span(ctx, "UPSERT").End()
+
+	var value int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, r.rebind(`INSERT INTO counters (name, value) VALUES (?, ?)
+			ON CONFLICT (name) DO UPDATE SET value = counters.value + excluded.value`), key, n)
+		if err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx, r.rebind(`SELECT value FROM counters WHERE name = ?`), key).Scan(&value)
+	})
+	return value, err
+}
+
+func (r *LinkRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *LinkRepository) affectedOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// span starts the span of a query of the links table, nothing is traced without a span in ctx
+func (r *LinkRepository) span(ctx context.Context, operation string) trace.Span {
+	_, span := tracing.Start(ctx, operation+" links", trace.SpanKindClient,
+		semconv.DBSystemKey.String(system(r.driver)), semconv.DBOperationKey.String(operation), semconv.DBSQLTableKey.String("links"))
+	return span
+}
+
+func (r *LinkRepository) rebind(query string) string {
+	return rebind(r.driver, query)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLink(row rowScanner) (*repository.Link, int64, error) {
+	link := new(repository.Link)
+	var deletedAt int64
+	err := row.Scan(&link.Code, &link.FullURL, &link.ExpireAt, &link.MaxHits, &link.Hits, &link.CreatedAt, &link.Version, &link.PasswordHash, &link.RedirectStatus, &link.Owner, &link.Flagged, &deletedAt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return link, deletedAt, nil
+}
+
+// rebind turns ? placeholders into $n for drivers that need it
+func rebind(driver string, query string) string {
+	if driver != "postgres" {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	for _, char := range query {
+		if char == '?' {
+			n++
+			builder.WriteString(fmt.Sprintf("$%d", n))
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// system is the db.system of driver
+func system(driver string) string {
+	if driver == "postgres" {
+		return "postgresql"
+	}
+	return "sqlite"
+}
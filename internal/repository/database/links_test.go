@@ -0,0 +1,345 @@
+package database
+
+import (
+	"context"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+type TSuite struct {
+	suite.Suite
+	dir   string
+	links *LinkRepository
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+func (s *TSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "links")
+	s.Require().NoError(err)
+	s.dir = dir
+
+	s.links, err = Open(repository.Config{Driver: "sqlite3", DSN: filepath.Join(dir, "links.db")})
+	s.Require().NoError(err)
+	s.links.now = func() time.Time { return time.Unix(1619766384, 0) }
+}
+
+func (s *TSuite) TearDownTest() {
+	_ = s.links.Close()
+	_ = os.RemoveAll(s.dir)
+}
+
+func (s *TSuite) create(code string, fullURL string) {
+	created, err := s.links.Create(context.Background(), repository.Link{Code: code, FullURL: fullURL, ExpireAt: 4102444800, MaxHits: 10, CreatedAt: 1619766384})
+	s.Require().NoError(err)
+	s.Require().True(created)
+}
+
+func (s *TSuite) TestMigrate_Idempotent() {
+	s.Require().NoError(Migrate(s.links.db, "sqlite3"))
+
+	var version int
+	s.Require().NoError(s.links.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	s.Assert().Equal(len(migrations), version)
+}
+
+func (s *TSuite) TestCreate_LongestNamespacedCode() {
+	code := strings.Repeat("n", 32) + "/" + strings.Repeat("a", 32)
+	s.create(code, "https://www.speedtest.net")
+
+	link, err := s.links.Get(context.Background(), code)
+	s.Require().NoError(err)
+	s.Assert().Equal(code, link.Code)
+}
+
+func (s *TSuite) TestCreate_CodeTaken() {
+	s.create("abc", "https://www.speedtest.net")
+
+	created, err := s.links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.example.com"})
+	s.Require().NoError(err)
+	s.Assert().False(created)
+
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, CreatedAt: 1619766384, Version: 1}, link)
+}
+
+func (s *TSuite) TestCreate_ReclaimsExpiredCode() {
+	// now is 1619766384 in tests
+	created, err := s.links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 1619766000})
+	s.Require().NoError(err)
+	s.Require().True(created)
+
+	created, err = s.links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.example.com"})
+	s.Require().NoError(err)
+	s.Assert().True(created)
+
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("https://www.example.com", link.FullURL)
+	s.Assert().Equal(int64(2), link.Version)
+}
+
+func (s *TSuite) TestCreate_PasswordHashAndRedirectStatus() {
+	created, err := s.links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", PasswordHash: "$2a$10$hash", RedirectStatus: 307})
+	s.Require().NoError(err)
+	s.Require().True(created)
+
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("$2a$10$hash", link.PasswordHash)
+	s.Assert().Equal(307, link.RedirectStatus)
+}
+
+func (s *TSuite) TestGet_NotFound() {
+	_, err := s.links.Get(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+}
+
+func (s *TSuite) TestDelete_LeavesTombstone() {
+	s.create("abc", "https://www.speedtest.net")
+
+	s.Require().NoError(s.links.Delete(context.Background(), "abc"))
+	_, err := s.links.Get(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrDeleted, err)
+	s.Assert().Equal(repository.ErrNotFound, s.links.Delete(context.Background(), "abc"))
+	_, err = s.links.IncrementHits(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+
+	// a deleted code can be created again
+	s.create("abc", "https://www.example.com")
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("https://www.example.com", link.FullURL)
+}
+
+func (s *TSuite) TestUpdate_KeepsHits() {
+	s.create("abc", "https://www.speedtest.net")
+	_, err := s.links.IncrementHits(context.Background(), "abc")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.links.Update(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", MaxHits: 20}))
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(20), link.MaxHits)
+	s.Assert().Equal(int64(0), link.ExpireAt)
+	s.Assert().Equal(int64(1), link.Hits)
+
+	s.Assert().Equal(repository.ErrNotFound, s.links.Update(context.Background(), repository.Link{Code: "def"}))
+}
+
+func (s *TSuite) TestUpdate_Versioned() {
+	s.create("abc", "https://www.speedtest.net")
+
+	s.Require().NoError(s.links.Update(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.example.com", Version: 1}))
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), link.Version)
+
+	s.Assert().Equal(repository.ErrVersionMismatch, s.links.Update(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", Version: 1}))
+
+	s.Require().NoError(s.links.Delete(context.Background(), "abc"))
+	s.Assert().Equal(repository.ErrDeleted, s.links.Update(context.Background(), repository.Link{Code: "abc", Version: 2}))
+
+	// a recreated code never reuses a version of the deleted link
+	s.create("abc", "https://www.speedtest.net")
+	link, err = s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(3), link.Version)
+}
+
+func (s *TSuite) TestFlag() {
+	s.create("abc", "https://www.speedtest.net")
+
+	s.Require().NoError(s.links.Flag(context.Background(), "abc", "MALWARE"))
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("MALWARE", link.Flagged)
+	s.Assert().Equal(int64(1), link.Version)
+
+	// updates keep the flag, the sweeper clears it
+	s.Require().NoError(s.links.Update(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.example.com"}))
+	link, err = s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("MALWARE", link.Flagged)
+	s.Require().NoError(s.links.Flag(context.Background(), "abc", ""))
+	link, err = s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Empty(link.Flagged)
+
+	// a code created again after its deletion starts unflagged
+	s.Require().NoError(s.links.Flag(context.Background(), "abc", "MALWARE"))
+	s.Require().NoError(s.links.Delete(context.Background(), "abc"))
+	s.Assert().Equal(repository.ErrNotFound, s.links.Flag(context.Background(), "abc", "MALWARE"))
+	s.create("abc", "https://www.speedtest.net")
+	link, err = s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Empty(link.Flagged)
+
+	s.Assert().Equal(repository.ErrNotFound, s.links.Flag(context.Background(), "unknown", "MALWARE"))
+}
+
+func (s *TSuite) TestIncrementHits() {
+	s.create("abc", "https://www.speedtest.net")
+
+	for want := int64(1); want <= 3; want++ {
+		hits, err := s.links.IncrementHits(context.Background(), "abc")
+		s.Require().NoError(err)
+		s.Assert().Equal(want, hits)
+	}
+	_, err := s.links.IncrementHits(context.Background(), "def")
+	s.Assert().Equal(repository.ErrNotFound, err)
+}
+
+func (s *TSuite) TestIncrementHits_QuotaExhausted() {
+	_, err := s.links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", MaxHits: 2})
+	s.Require().NoError(err)
+
+	for want := int64(1); want <= 2; want++ {
+		hits, err := s.links.IncrementHits(context.Background(), "abc")
+		s.Require().NoError(err)
+		s.Assert().Equal(want, hits)
+	}
+	_, err = s.links.IncrementHits(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrHitsExceeded, err)
+
+	// rejected visits are not counted
+	link, err := s.links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), link.Hits)
+}
+
+func (s *TSuite) TestList_Pages() {
+	s.create("abc", "https://www.SpeedTest.net")
+	s.create("abd", "https://www.example.com")
+	s.create("a_c", "https://www.speedtest.net/run")
+	s.create("xyz", "https://www.speedtest.net/xyz")
+	s.Require().NoError(s.links.Delete(context.Background(), "xyz"))
+
+	page, cursor, err := s.links.List(context.Background(), repository.ListFilter{Limit: 2})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"a_c", "abc"}, codes(page))
+	s.Assert().Equal("abc", cursor)
+
+	page, cursor, err = s.links.List(context.Background(), repository.ListFilter{Cursor: cursor, Limit: 2})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"abd"}, codes(page))
+	s.Assert().Equal("", cursor)
+}
+
+func (s *TSuite) TestList_FilterByCodeAndKeyword() {
+	s.create("abc", "https://www.SpeedTest.net")
+	s.create("abd", "https://www.example.com")
+	s.create("a_c", "https://www.speedtest.net/run")
+
+	// the prefix is matched literally, _ is not a wildcard
+	page, _, err := s.links.List(context.Background(), repository.ListFilter{Limit: 10, CodePrefix: "a_"})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"a_c"}, codes(page))
+
+	page, _, err = s.links.List(context.Background(), repository.ListFilter{Limit: 10, CodePrefix: "ab", Keyword: "speedtest"})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"abc"}, codes(page))
+}
+
+func (s *TSuite) TestIncr() {
+	for want := int64(1); want <= 2; want++ {
+		value, err := s.links.Incr(context.Background(), "counter")
+		s.Require().NoError(err)
+		s.Assert().Equal(want, value)
+	}
+}
+
+func (s *TSuite) TestIncrBy() {
+	value, err := s.links.IncrBy(context.Background(), "counter", 3)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(3), value)
+
+	value, err = s.links.IncrBy(context.Background(), "counter", 2)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(5), value)
+}
+
+func TestRebind(t *testing.T) {
+	query := `SELECT 1 FROM links WHERE code = ? AND hits > ?`
+	if got := rebind("postgres", query); got != `SELECT 1 FROM links WHERE code = $1 AND hits > $2` {
+		t.Errorf("rebind postgres = %v", got)
+	}
+	if got := rebind("sqlite3", query); got != query {
+		t.Errorf("rebind sqlite3 = %v", got)
+	}
+}
+
+func codes(links []repository.Link) []string {
+	codes := make([]string, 0, len(links))
+	for _, link := range links {
+		codes = append(codes, link.Code)
+	}
+	return codes
+}
+
+func (s *TSuite) TestCreateBatch() {
+	s.create("abc", "https://www.speedtest.net")
+
+	created, err := s.links.CreateBatch(context.Background(), []repository.Link{
+		{Code: "abc", FullURL: "https://www.example.com"},
+		{Code: "def", FullURL: "https://www.example.com"},
+		{Code: "def", FullURL: "https://www.other.net"},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]bool{false, true, false}, created)
+
+	link, err := s.links.Get(context.Background(), "def")
+	s.Require().NoError(err)
+	s.Assert().Equal("https://www.example.com", link.FullURL)
+}
+
+func (s *TSuite) TestList_FilterByOwner() {
+	for code, owner := range map[string]string{"abc": "acme", "abd": "globex", "abe": ""} {
+		created, err := s.links.Create(context.Background(), repository.Link{Code: code, FullURL: "https://www.speedtest.net", Owner: owner})
+		s.Require().NoError(err)
+		s.Require().True(created)
+	}
+
+	page, _, err := s.links.List(context.Background(), repository.ListFilter{Limit: 10, Owner: "acme"})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"abc"}, codes(page))
+	s.Assert().Equal("acme", page[0].Owner)
+}
+
+func (s *TSuite) TestKeys() {
+	key := repository.APIKey{ID: "k2", Tenant: "acme", Hash: "hash", Scopes: []string{"create", "delete"}, CreatedAt: 1619766384}
+	s.Require().NoError(s.links.CreateKey(context.Background(), key))
+	s.Require().NoError(s.links.CreateKey(context.Background(), repository.APIKey{ID: "k1", Hash: "other", Scopes: []string{"admin"}}))
+	s.Assert().Error(s.links.CreateKey(context.Background(), key))
+
+	got, err := s.links.GetKey(context.Background(), "k2")
+	s.Require().NoError(err)
+	s.Assert().Equal(&key, got)
+
+	list, err := s.links.ListKeys(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(list, 2)
+	s.Assert().Equal("k1", list[0].ID)
+
+	s.Require().NoError(s.links.DeleteKey(context.Background(), "k2"))
+	_, err = s.links.GetKey(context.Background(), "k2")
+	s.Assert().Equal(repository.ErrNotFound, err)
+	s.Assert().Equal(repository.ErrNotFound, s.links.DeleteKey(context.Background(), "k2"))
+}
+
+func (s *TSuite) TestPing() {
+	s.Assert().NoError(s.links.Ping(context.Background()))
+
+	_ = s.links.Close()
+	s.Assert().Error(s.links.Ping(context.Background()))
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order, a migration is never edited once released: append a new one instead
+var migrations = []string{
+	`CREATE TABLE links (
+		code       VARCHAR(64) PRIMARY KEY,
+		full_url   TEXT        NOT NULL,
+		expire_at  BIGINT      NOT NULL DEFAULT 0,
+		max_hits   BIGINT      NOT NULL DEFAULT 0,
+		hits       BIGINT      NOT NULL DEFAULT 0,
+		created_at BIGINT      NOT NULL DEFAULT 0,
+		deleted_at BIGINT      NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE counters (
+		name  VARCHAR(128) PRIMARY KEY,
+		value BIGINT       NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE clicks (
+		code       VARCHAR(64)  NOT NULL,
+		clicked_at BIGINT       NOT NULL,
+		day        CHAR(10)     NOT NULL,
+		referrer   VARCHAR(255) NOT NULL DEFAULT '',
+		user_agent TEXT         NOT NULL DEFAULT '',
+		country    VARCHAR(8)   NOT NULL DEFAULT '',
+		ip_hash    VARCHAR(64)  NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX clicks_code_day ON clicks (code, day)`,
+	`CREATE TABLE link_index (
+		name      VARCHAR(255) PRIMARY KEY,
+		code      VARCHAR(64)  NOT NULL,
+		expire_at BIGINT       NOT NULL DEFAULT 0
+	)`,
+	`ALTER TABLE links ADD COLUMN version BIGINT NOT NULL DEFAULT 1`,
+	`ALTER TABLE links ADD COLUMN password_hash VARCHAR(100) NOT NULL DEFAULT ''`,
+	`ALTER TABLE links ADD COLUMN redirect_status INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE links ADD COLUMN owner VARCHAR(64) NOT NULL DEFAULT ''`,
+	`CREATE INDEX links_owner_code ON links (owner, code)`,
+	`CREATE TABLE api_keys (
+		id         VARCHAR(32)  PRIMARY KEY,
+		tenant     VARCHAR(64)  NOT NULL,
+		hash       VARCHAR(64)  NOT NULL,
+		scopes     VARCHAR(255) NOT NULL,
+		created_at BIGINT       NOT NULL DEFAULT 0
+	)`,
+	`ALTER TABLE links ADD COLUMN flagged VARCHAR(64) NOT NULL DEFAULT ''`,
+	// namespaced codes are up to 32 characters of namespace, a slash and 32 characters of alias
+	`ALTER TABLE links ALTER COLUMN code TYPE VARCHAR(128);
+	ALTER TABLE clicks ALTER COLUMN code TYPE VARCHAR(128);
+	ALTER TABLE link_index ALTER COLUMN code TYPE VARCHAR(128)`,
+	`CREATE TABLE blacklist_patterns (
+		pattern VARCHAR(1024) PRIMARY KEY
+	)`,
+}
+
+// sqliteMigrations replace the migrations SQLite can not run by version, an empty one is only recorded.
+// SQLite does not enforce the length of VARCHAR columns.
+var sqliteMigrations = map[int]string{
+	13: "",
+}
+
+// Migrate brings the schema up to date, every migration runs in its own transaction
+func Migrate(db *sql.DB, driver string) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %v", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %v", err)
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		statement := migrations[version-1]
+		if replaced, ok := sqliteMigrations[version]; ok && driver != "postgres" {
+			statement = replaced
+		}
+		if statement != "" {
+			_, err = tx.Exec(statement)
+		}
+		if err == nil {
+			_, err = tx.Exec(rebind(driver, `INSERT INTO schema_migrations (version) VALUES (?)`), version)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("apply migration %v: %v", version, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %v: %v", version, err)
+		}
+	}
+	return nil
+}
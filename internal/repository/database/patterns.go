@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+)
+
+// AddPattern implements repository.PatternRepository with the blacklist_patterns table
+func (r *LinkRepository) AddPattern(ctx context.Context, pattern string) error {
+	defer r.span(ctx, "INSERT").End()
+
+	_, err := r.db.ExecContext(ctx, r.rebind(`INSERT INTO blacklist_patterns (pattern) VALUES (?) ON CONFLICT (pattern) DO NOTHING`), pattern)
+	return err
+}
+
+func (r *LinkRepository) RemovePattern(ctx context.Context, pattern string) error {
+	defer r.span(ctx, "DELETE").End()
+
+	result, err := r.db.ExecContext(ctx, r.rebind(`DELETE FROM blacklist_patterns WHERE pattern = ?`), pattern)
+	return r.affectedOne(result, err)
+}
+
+func (r *LinkRepository) ListPatterns(ctx context.Context) ([]string, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT pattern FROM blacklist_patterns ORDER BY pattern`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patterns := []string{}
+	for rows.Next() {
+		var pattern string
+		if err = rows.Scan(&pattern); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, rows.Err()
+}
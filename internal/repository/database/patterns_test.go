@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestPatterns_AddListRemove() {
+	s.Require().NoError(s.links.AddPattern(context.Background(), `spam\.example`))
+	s.Require().NoError(s.links.AddPattern(context.Background(), `phishing\.example`))
+	s.Require().NoError(s.links.AddPattern(context.Background(), `spam\.example`))
+
+	patterns, err := s.links.ListPatterns(context.Background())
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{`phishing\.example`, `spam\.example`}, patterns)
+
+	s.Require().NoError(s.links.RemovePattern(context.Background(), `spam\.example`))
+	s.Assert().Equal(repository.ErrNotFound, s.links.RemovePattern(context.Background(), `spam\.example`))
+
+	patterns, err = s.links.ListPatterns(context.Background())
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{`phishing\.example`}, patterns)
+}
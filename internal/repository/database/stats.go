@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"url-shortener/internal/repository"
+)
+
+// RecordClick keeps every click as a row of the clicks table, breakdowns are computed on read
+func (r *LinkRepository) RecordClick(ctx context.Context, click repository.Click) error {
+	defer r.span(ctx, "INSERT").End()
+
+	_, err := r.db.ExecContext(ctx, r.rebind(`INSERT INTO clicks (code, clicked_at, day, referrer, user_agent, country, ip_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		click.Code, click.Timestamp, click.Day, click.Referrer, click.UserAgent, click.Country, click.IPHash)
+	return err
+}
+
+func (r *LinkRepository) Stats(ctx context.Context, code string) (*repository.LinkStats, error) {
+	defer r.span(ctx, "SELECT").End()
+
+	stats := repository.NewLinkStats()
+	breakdowns := []struct {
+		column string
+		counts map[string]int64
+	}{
+		{"day", stats.Days},
+		{"referrer", stats.Referrers},
+		{"country", stats.Countries},
+	}
+	for _, breakdown := range breakdowns {
+		if err := r.countBy(ctx, breakdown.column, code, breakdown.counts); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, count := range stats.Days {
+		stats.Total += count
+	}
+	return stats, nil
+}
+
+// countBy fills counts with the number of clicks on code per value of column
+func (r *LinkRepository) countBy(ctx context.Context, column string, code string, counts map[string]int64) error {
+	rows, err := r.db.QueryContext(ctx, r.rebind(`SELECT `+column+`, COUNT(*) FROM clicks WHERE code = ? GROUP BY `+column), code)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		var count int64
+		if err = rows.Scan(&value, &count); err != nil {
+			return err
+		}
+		counts[value] = count
+	}
+	return rows.Err()
+}
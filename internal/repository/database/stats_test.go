@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"url-shortener/internal/repository"
+)
+
+func (s *TSuite) TestStats_Breakdown() {
+	clicks := []repository.Click{
+		{Code: "abc", Timestamp: 1619680000, Day: "2021-04-29", Referrer: "direct", Country: "TH"},
+		{Code: "abc", Timestamp: 1619766384, Day: "2021-04-30", Referrer: "news.example.com", Country: "TH"},
+		{Code: "abc", Timestamp: 1619766385, Day: "2021-04-30", Referrer: "direct", Country: "unknown"},
+		{Code: "def", Timestamp: 1619766384, Day: "2021-04-30", Referrer: "direct", Country: "TH"},
+	}
+	for _, click := range clicks {
+		s.Require().NoError(s.links.RecordClick(context.Background(), click))
+	}
+
+	stats, err := s.links.Stats(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(&repository.LinkStats{
+		Total:     3,
+		Days:      map[string]int64{"2021-04-29": 1, "2021-04-30": 2},
+		Referrers: map[string]int64{"direct": 2, "news.example.com": 1},
+		Countries: map[string]int64{"TH": 2, "unknown": 1},
+	}, stats)
+
+	stats, err = s.links.Stats(context.Background(), "xyz")
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(0), stats.Total)
+}
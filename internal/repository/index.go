@@ -0,0 +1,54 @@
+package repository
+
+//go:generate mockgen -source=./index.go -destination=./mocks/index.go
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// IndexRepository maps lookup keys to short codes, such as destination urls and idempotency keys.
+// Entries are hints: the link a code points to may have been deleted or replaced since.
+type IndexRepository interface {
+	// Put stores code under key until expireAt (unix seconds, 0 keeps it), replacing any previous code
+	Put(ctx context.Context, key string, code string, expireAt int64) error
+	// PutBatch stores every entry like Put in one round trip, a key listed twice keeps its last code
+	PutBatch(ctx context.Context, entries []IndexEntry) error
+	// Claim stores code under key unless another code is stored there and returns the code kept under key
+	Claim(ctx context.Context, key string, code string, expireAt int64) (string, error)
+	// Lookup returns the code stored under key, ErrNotFound when there is none
+	Lookup(ctx context.Context, key string) (string, error)
+}
+
+// IndexEntry is one code stored by PutBatch under Key until ExpireAt
+type IndexEntry struct {
+	Key      string
+	Code     string
+	ExpireAt int64
+}
+
+// URLKey is the index key of the links of tenant shortening fullURL in the code namespace of a domain
+func URLKey(tenant string, namespace string, fullURL string) string {
+	sum := sha256.Sum256([]byte(fullURL))
+	key := "url:" + tenantPrefix(tenant)
+	if namespace != "" {
+		key += namespace + ":"
+	}
+	return key + hex.EncodeToString(sum[:])
+}
+
+// IdempotencyKey is the index key of the link created by a request of tenant carrying the Idempotency-Key header.
+// The header is hashed like urls so a client can not shape the key, such as making it end like the key of a link.
+func IdempotencyKey(tenant string, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "idempotency:" + tenantPrefix(tenant) + hex.EncodeToString(sum[:])
+}
+
+// tenantPrefix keeps the entries of tenants apart, links without owner keep the keys they had before tenants
+func tenantPrefix(tenant string) string {
+	if tenant == "" {
+		return ""
+	}
+	return tenant + "/"
+}
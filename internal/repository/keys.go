@@ -0,0 +1,25 @@
+package repository
+
+//go:generate mockgen -source=./keys.go -destination=./mocks/keys.go
+
+import (
+	"context"
+)
+
+// APIKey authenticates the clients of a tenant. Only the sha256 hash of its secret is stored.
+type APIKey struct {
+	ID        string
+	Tenant    string
+	Hash      string
+	Scopes    []string
+	CreatedAt int64
+}
+
+// KeyRepository stores API keys. GetKey and DeleteKey return ErrNotFound for unknown ids.
+type KeyRepository interface {
+	CreateKey(ctx context.Context, key APIKey) error
+	GetKey(ctx context.Context, id string) (*APIKey, error)
+	// ListKeys returns every key ordered by id
+	ListKeys(ctx context.Context) ([]APIKey, error)
+	DeleteKey(ctx context.Context, id string) error
+}
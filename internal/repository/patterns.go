@@ -0,0 +1,16 @@
+package repository
+
+//go:generate mockgen -source=./patterns.go -destination=./mocks/patterns.go
+
+import (
+	"context"
+)
+
+// PatternRepository stores the blacklist patterns added at runtime so every instance shares them
+type PatternRepository interface {
+	// AddPattern stores pattern, storing a known pattern again is a no-op
+	AddPattern(ctx context.Context, pattern string) error
+	// RemovePattern returns ErrNotFound when pattern is not stored
+	RemovePattern(ctx context.Context, pattern string) error
+	ListPatterns(ctx context.Context) ([]string, error)
+}
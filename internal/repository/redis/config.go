@@ -1,8 +1,24 @@
 package redis
 
+import "time"
+
+const day = 24 * time.Hour
+
+// DefaultExpiry is the expiry of links created without one, 0 when they never expire
+func (config Config) DefaultExpiry() time.Duration {
+	return time.Duration(config.Expire) * day
+}
+
+// MaxExpiry is the latest expiry a link can have, 0 when there is no limit
+func (config Config) MaxExpiry() time.Duration {
+	return time.Duration(config.MaxExpire) * day
+}
+
 type Config struct {
 	Key         string
 	Expire      int64
+	MaxExpire   int64
+	Retention   int64
 	RedisServer Server
 }
 
@@ -11,3 +27,19 @@ type Server struct {
 	Port    string
 	Db      int
 }
+
+// TTL returns how long Redis keeps a link expiring at expireAt (unix seconds).
+// Expired links are kept for Retention days so visitors get 410 before the key is reclaimed,
+// links without expiry are kept forever.
+func (config Config) TTL(expireAt int64, now time.Time) time.Duration {
+	if expireAt <= 0 {
+		return 0
+	}
+
+	ttl := time.Unix(expireAt, 0).Sub(now) + time.Duration(config.Retention)*day
+	if ttl <= 0 {
+		// already past retention, keep it briefly instead of persisting it forever
+		return time.Second
+	}
+	return ttl
+}
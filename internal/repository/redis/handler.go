@@ -3,10 +3,16 @@ package redis
 //go:generate mockgen -source=./handler.go -destination=./mocks/handler.go
 
 import (
+	"context"
+	"fmt"
 	"github.com/go-redis/redis"
-	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/rs/zerolog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"strings"
 	"time"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/tracing"
 )
 
 var (
@@ -16,14 +22,47 @@ var (
 type HandlerInterface interface {
 	Connect(config Config) error
 	Disconnect()
-	Set(key string, value interface{}, exp time.Duration, txn *newrelic.Transaction) error
-	Get(key string, txn *newrelic.Transaction) (string, error)
-	Del(key string, txn *newrelic.Transaction) error
+	Ping(ctx context.Context) error
+	Set(ctx context.Context, key string, value interface{}, exp time.Duration) error
+	SetBatch(ctx context.Context, entries []StringEntry) error
+	SetNX(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (string, error)
+	Del(ctx context.Context, keys []string) (int64, error)
+	Incr(ctx context.Context, key string) (int64, error)
+	IncrBy(ctx context.Context, key string, n int64) (int64, error)
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	HMSet(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) error
+	HMSetNX(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (bool, error)
+	HMSetNXBatch(ctx context.Context, entries []HashEntry) ([]bool, error)
+	HMSetVersion(ctx context.Context, key string, fields map[string]interface{}, version int64, exp time.Duration) (int64, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	SAdd(ctx context.Context, key string, member string) error
+	SRem(ctx context.Context, key string, member string) (bool, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	HIncrByMax(ctx context.Context, key string, field string, maxField string) (int64, error)
+	HSetXX(ctx context.Context, key string, field string, value interface{}) (bool, error)
+	HDelTombstone(ctx context.Context, key string, field string, tombstone string, value interface{}, exp time.Duration) (bool, error)
+	HIncrByFields(ctx context.Context, key string, fields map[string]int64, exp time.Duration) error
+	IncrWindow(ctx context.Context, key string, previous string, exp time.Duration) (int64, int64, error)
 }
 type Handler struct {
 	client *redis.Client
 }
 
+// HashEntry is one hash written by HMSetNXBatch
+type HashEntry struct {
+	Key    string
+	Fields map[string]interface{}
+	Exp    time.Duration
+}
+
+// StringEntry is one string written by SetBatch
+type StringEntry struct {
+	Key   string
+	Value interface{}
+	Exp   time.Duration
+}
+
 func (handler *Handler) Connect(config Config) error {
 	address := config.RedisServer.Address + ":" + config.RedisServer.Port
 
@@ -49,30 +88,43 @@ func (handler *Handler) Disconnect() {
 	}
 }
 
-func (handler *Handler) Set(key string, value interface{}, exp time.Duration, txn *newrelic.Transaction) error {
-	segment := newrelic.DatastoreSegment{
-		StartTime:          txn.StartSegmentNow(),
-		Product:            newrelic.DatastoreRedis,
-		Operation:          "SET",
-		ParameterizedQuery: key,
-	}
-	defer segment.End()
+// Ping checks Redis answers, used by the readiness check
+func (handler *Handler) Ping(ctx context.Context) (err error) {
+	defer trace(ctx, "PING", "")(&err)
 
-	return handler.client.Set(key, value, exp).Err()
+	return handler.client.WithContext(ctx).Ping().Err()
 }
 
-func (handler *Handler) Get(key string, txn *newrelic.Transaction) (string, error) {
+func (handler *Handler) Set(ctx context.Context, key string, value interface{}, exp time.Duration) (err error) {
+	defer trace(ctx, "SET", key)(&err)
 
-	segment := newrelic.DatastoreSegment{
-		StartTime:          txn.StartSegmentNow(),
-		Product:            newrelic.DatastoreRedis,
-		Operation:          "GET",
-		ParameterizedQuery: key,
-	}
+	return handler.client.WithContext(ctx).Set(key, value, exp).Err()
+}
+
+// SetBatch sets every entry like Set in a single pipeline
+func (handler *Handler) SetBatch(ctx context.Context, entries []StringEntry) (err error) {
+	defer trace(ctx, "SET", "batch")(&err)
+
+	_, err = handler.client.WithContext(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for _, entry := range entries {
+			pipe.Set(entry.Key, entry.Value, entry.Exp)
+		}
+		return nil
+	})
+	return err
+}
 
-	defer segment.End()
+// SetNX sets key only when it does not exist yet and reports whether it was set.
+func (handler *Handler) SetNX(ctx context.Context, key string, value interface{}, exp time.Duration) (set bool, err error) {
+	defer trace(ctx, "SETNX", key)(&err)
 
-	result, err := handler.client.Get(key).Result()
+	return handler.client.WithContext(ctx).SetNX(key, value, exp).Result()
+}
+
+func (handler *Handler) Get(ctx context.Context, key string) (value string, err error) {
+	defer trace(ctx, "GET", key)(&err)
+
+	result, err := handler.client.WithContext(ctx).Get(key).Result()
 	if err == redis.Nil {
 		return "", nil
 	} else if err != nil {
@@ -82,21 +134,305 @@ func (handler *Handler) Get(key string, txn *newrelic.Transaction) (string, erro
 	return result, err
 }
 
-func (handler *Handler) Del(key string, txn *newrelic.Transaction) error {
+// Del removes the given keys and returns how many of them existed.
+// Keys are matched literally, DEL does not expand glob patterns.
+func (handler *Handler) Del(ctx context.Context, keys []string) (deleted int64, err error) {
+	defer trace(ctx, "DELETE", strings.Join(keys, " "))(&err)
+
+	return handler.client.WithContext(ctx).Del(keys...).Result()
+}
+
+// Incr atomically increments the integer stored at key and returns the new value.
+func (handler *Handler) Incr(ctx context.Context, key string) (value int64, err error) {
+	defer trace(ctx, "INCR", key)(&err)
+
+	return handler.client.WithContext(ctx).Incr(key).Result()
+}
+
+// IncrBy atomically adds n to the integer stored at key and returns the new value.
+func (handler *Handler) IncrBy(ctx context.Context, key string, n int64) (value int64, err error) {
+	defer trace(ctx, "INCRBY", key)(&err)
+
+	return handler.client.WithContext(ctx).IncrBy(key, n).Result()
+}
+
+// Scan runs one SCAN iteration and returns the matched keys with the cursor to continue from.
+func (handler *Handler) Scan(ctx context.Context, cursor uint64, match string, count int64) (keys []string, next uint64, err error) {
+	defer trace(ctx, "SCAN", match)(&err)
+
+	return handler.client.WithContext(ctx).Scan(cursor, match, count).Result()
+}
+
+// SAdd adds member to the set at key
+func (handler *Handler) SAdd(ctx context.Context, key string, member string) (err error) {
+	defer trace(ctx, "SADD", key)(&err)
+
+	return handler.client.WithContext(ctx).SAdd(key, member).Err()
+}
+
+// SRem removes member from the set at key and reports whether it was a member
+func (handler *Handler) SRem(ctx context.Context, key string, member string) (removed bool, err error) {
+	defer trace(ctx, "SREM", key)(&err)
+
+	count, err := handler.client.WithContext(ctx).SRem(key, member).Result()
+	return count > 0, err
+}
+
+// SMembers returns every member of the set at key
+func (handler *Handler) SMembers(ctx context.Context, key string) (members []string, err error) {
+	defer trace(ctx, "SMEMBERS", key)(&err)
+
+	return handler.client.WithContext(ctx).SMembers(key).Result()
+}
+
+// hIncrByMax increments a hash field only when the hash exists so an expired link is never recreated without TTL,
+// and only while it is below the limit in the field ARGV[2]
+var hIncrByMax = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local max = tonumber(redis.call("HGET", KEYS[1], ARGV[2]) or "0") or 0
+if max > 0 and (tonumber(redis.call("HGET", KEYS[1], ARGV[1]) or "0") or 0) >= max then
+	return -1
+end
+return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+`)
+
+// hSetXX sets a hash field only when the hash exists, its TTL is kept
+var hSetXX = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// hDelTombstone deletes the hash at KEYS[1] when it holds the field ARGV[1] and writes the tombstone KEYS[2]
+// in its place. ARGV[2] is the value of the tombstone and ARGV[3] its TTL in ms, 0 writes no tombstone.
+var hDelTombstone = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+end
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// hmSetNX writes a hash with its TTL only when the key does not exist, ARGV holds the TTL in ms then field value pairs
+var hmSetNX = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+redis.call("HMSET", KEYS[1], unpack(ARGV, 2))
+if tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// hmSetVersion overwrites fields of an existing hash at the version in ARGV[2] (0 matches any) and bumps
+// its version field, a hash without one is at version 1. ARGV[1] is the TTL in ms, 0 removes it.
+var hmSetVersion = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local version = tonumber(redis.call("HGET", KEYS[1], "version") or "1")
+if tonumber(ARGV[2]) > 0 and tonumber(ARGV[2]) ~= version then
+	return -1
+end
+redis.call("HMSET", KEYS[1], unpack(ARGV, 3))
+redis.call("HSET", KEYS[1], "version", version + 1)
+if tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+else
+	redis.call("PERSIST", KEYS[1])
+end
+return version + 1
+`)
+
+// HMSet writes fields into the hash at key and sets its TTL in one MULTI/EXEC, exp=0 removes the TTL.
+func (handler *Handler) HMSet(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (err error) {
+	defer trace(ctx, "HMSET", key)(&err)
+
+	_, err = handler.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		setWithTTL(pipe, key, fields, exp)
+		return nil
+	})
+	return err
+}
 
-	segment := newrelic.DatastoreSegment{
-		StartTime:          txn.StartSegmentNow(),
-		Product:            newrelic.DatastoreRedis,
-		Operation:          "DELETE",
-		ParameterizedQuery: key,
+// HMSetNX creates the hash at key with its TTL only when key does not exist yet and reports whether it was created.
+func (handler *Handler) HMSetNX(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (created bool, err error) {
+	defer trace(ctx, "HMSETNX", key)(&err)
+
+	err = handler.client.WithContext(ctx).Watch(func(tx *redis.Tx) error {
+		exists, err := tx.Exists(key).Result()
+		if err != nil || exists > 0 {
+			return err
+		}
+
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			setWithTTL(pipe, key, fields, exp)
+			return nil
+		})
+		created = err == nil
+		return err
+	}, key)
+
+	// somebody else wrote the key between WATCH and EXEC
+	if err == redis.TxFailedErr {
+		return false, nil
 	}
+	return created, err
+}
 
-	defer segment.End()
+// HMSetNXBatch writes every entry like HMSetNX in a single pipeline and reports which entries were created.
+// An entry listed twice is only created once.
+func (handler *Handler) HMSetNXBatch(ctx context.Context, entries []HashEntry) (created []bool, err error) {
+	defer trace(ctx, "HMSETNX", "batch")(&err)
 
-	err := handler.client.Del(key)
+	cmds := make([]*redis.Cmd, len(entries))
+	_, err = handler.client.WithContext(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for i, entry := range entries {
+			args := []interface{}{int64(entry.Exp / time.Millisecond)}
+			for field, value := range entry.Fields {
+				args = append(args, field, value)
+			}
+			cmds[i] = hmSetNX.Eval(pipe, []string{entry.Key}, args...)
+		}
+		return nil
+	})
 	if err != nil {
-		return err.Err()
+		return nil, err
 	}
 
-	return nil
+	created = make([]bool, len(entries))
+	for i, cmd := range cmds {
+		value, err := cmd.Int64()
+		if err != nil {
+			return nil, err
+		}
+		created[i] = value == 1
+	}
+	return created, nil
+}
+
+// HMSetVersion writes fields into the existing hash at key when its version field equals version,
+// or whatever it is when version is 0, and returns the bumped version. It returns 0 without writing
+// anything when key does not exist and -1 when the version differs. exp=0 removes the TTL.
+func (handler *Handler) HMSetVersion(ctx context.Context, key string, fields map[string]interface{}, version int64, exp time.Duration) (bumped int64, err error) {
+	defer trace(ctx, "HMSET", key)(&err)
+
+	args := []interface{}{int64(exp / time.Millisecond), version}
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	return hmSetVersion.Run(handler.client.WithContext(ctx), []string{key}, args...).Int64()
+}
+
+// HGetAll returns every field of the hash at key, an empty map when key does not exist.
+func (handler *Handler) HGetAll(ctx context.Context, key string) (fields map[string]string, err error) {
+	defer trace(ctx, "HGETALL", key)(&err)
+
+	return handler.client.WithContext(ctx).HGetAll(key).Result()
+}
+
+// HIncrByMax atomically increments field of an existing hash by one while it is below the value of maxField
+// and returns the new value, a missing or zero maxField does not limit it. It returns 0 without creating
+// anything when key does not exist and -1 without incrementing once field reached maxField.
+func (handler *Handler) HIncrByMax(ctx context.Context, key string, field string, maxField string) (value int64, err error) {
+	defer trace(ctx, "HINCRBY", key)(&err)
+
+	return hIncrByMax.Run(handler.client.WithContext(ctx), []string{key}, field, maxField).Int64()
+}
+
+// HSetXX sets field of the existing hash at key and reports whether key exists, a missing key is not created.
+func (handler *Handler) HSetXX(ctx context.Context, key string, field string, value interface{}) (exists bool, err error) {
+	defer trace(ctx, "HSET", key)(&err)
+
+	result, err := hSetXX.Run(handler.client.WithContext(ctx), []string{key}, field, value).Int64()
+	return result == 1, err
+}
+
+// HDelTombstone atomically deletes the hash at key when it holds field and writes value at tombstone
+// for exp, it reports whether the hash was deleted. An exp of zero deletes without tombstone.
+func (handler *Handler) HDelTombstone(ctx context.Context, key string, field string, tombstone string, value interface{}, exp time.Duration) (deleted bool, err error) {
+	defer trace(ctx, "DELETE", key)(&err)
+
+	result, err := hDelTombstone.Run(handler.client.WithContext(ctx), []string{key, tombstone}, field, value, int64(exp/time.Millisecond)).Int64()
+	return result == 1, err
+}
+
+// HIncrByFields increments every field of the hash at key, creating it when needed, and
+// pushes its TTL back to exp. An exp of zero leaves the TTL untouched.
+func (handler *Handler) HIncrByFields(ctx context.Context, key string, fields map[string]int64, exp time.Duration) (err error) {
+	defer trace(ctx, "HINCRBY", key)(&err)
+
+	_, err = handler.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		for field, incr := range fields {
+			pipe.HIncrBy(key, field, incr)
+		}
+		if exp > 0 {
+			pipe.PExpire(key, exp)
+		}
+		return nil
+	})
+	return err
+}
+
+// incrWindow counts a hit in the window counter KEYS[1], which expires ARGV[1] ms after its first hit,
+// and returns it with the counter of the previous window KEYS[2]
+var incrWindow = redis.NewScript(`
+local current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {current, tonumber(redis.call("GET", KEYS[2]) or "0")}
+`)
+
+// IncrWindow atomically increments the counter at key, setting its TTL to exp on the first increment,
+// and returns it with the value of the counter at previous, 0 when previous does not exist.
+func (handler *Handler) IncrWindow(ctx context.Context, key string, previous string, exp time.Duration) (current int64, last int64, err error) {
+	defer trace(ctx, "INCR", key)(&err)
+
+	result, err := incrWindow.Run(handler.client.WithContext(ctx), []string{key, previous}, int64(exp/time.Millisecond)).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	counters, ok := result.([]interface{})
+	if !ok || len(counters) != 2 {
+		return 0, 0, fmt.Errorf("unexpected window counters %v", result)
+	}
+	current, _ = counters[0].(int64)
+	last, _ = counters[1].(int64)
+	return current, last, nil
+}
+
+// trace starts the span of a Redis operation on key, the returned func ends it once *err is known and
+// records the latency of the operation, counting it as failed when *err is set. redis.Nil is an answer,
+// not a failure.
+func trace(ctx context.Context, operation string, key string) func(err *error) {
+	start := time.Now()
+	_, span := tracing.Start(ctx, operation, oteltrace.SpanKindClient,
+		semconv.DBSystemRedis, semconv.DBOperationKey.String(operation), semconv.DBStatementKey.String(key))
+
+	return func(err *error) {
+		metrics.RedisDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		if *err != nil && *err != redis.Nil {
+			metrics.RedisErrors.WithLabelValues(operation).Inc()
+			tracing.Fail(span, *err)
+		}
+		span.End()
+	}
+}
+
+func setWithTTL(pipe redis.Pipeliner, key string, fields map[string]interface{}, exp time.Duration) {
+	pipe.HMSet(key, fields)
+	if exp > 0 {
+		pipe.PExpire(key, exp)
+	} else {
+		pipe.Persist(key)
+	}
 }
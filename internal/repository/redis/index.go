@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"context"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+// claimAttempts bounds Claim retries when the entry expires between SETNX and GET
+const claimAttempts = 2
+
+// IndexRepository keeps every index entry as one `{Key}index:{key}` string holding a short code
+type IndexRepository struct {
+	Handler HandlerInterface
+	Config  Config
+	now     func() time.Time
+}
+
+func NewIndexRepository(handler HandlerInterface, config Config) *IndexRepository {
+	return &IndexRepository{
+		Handler: handler,
+		Config:  config,
+		now:     time.Now,
+	}
+}
+
+func (r *IndexRepository) Put(ctx context.Context, key string, code string, expireAt int64) error {
+	return r.Handler.Set(ctx, r.key(key), code, r.ttl(expireAt))
+}
+
+func (r *IndexRepository) PutBatch(ctx context.Context, entries []repository.IndexEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	values := make([]StringEntry, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, StringEntry{Key: r.key(entry.Key), Value: entry.Code, Exp: r.ttl(entry.ExpireAt)})
+	}
+	return r.Handler.SetBatch(ctx, values)
+}
+
+func (r *IndexRepository) Claim(ctx context.Context, key string, code string, expireAt int64) (string, error) {
+	for attempt := 0; attempt < claimAttempts; attempt++ {
+		claimed, err := r.Handler.SetNX(ctx, r.key(key), code, r.ttl(expireAt))
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return code, nil
+		}
+
+		kept, err := r.Handler.Get(ctx, r.key(key))
+		if err != nil {
+			return "", err
+		}
+		if kept != "" {
+			return kept, nil
+		}
+	}
+	return "", repository.ErrNotFound
+}
+
+func (r *IndexRepository) Lookup(ctx context.Context, key string) (string, error) {
+	code, err := r.Handler.Get(ctx, r.key(key))
+	if err != nil {
+		return "", err
+	}
+	if code == "" {
+		return "", repository.ErrNotFound
+	}
+	return code, nil
+}
+
+// ttl keeps entries until expireAt, entries without expiry are kept forever
+func (r *IndexRepository) ttl(expireAt int64) time.Duration {
+	if expireAt <= 0 {
+		return 0
+	}
+
+	ttl := time.Unix(expireAt, 0).Sub(r.now())
+	if ttl <= 0 {
+		return time.Second
+	}
+	return ttl
+}
+
+func (r *IndexRepository) key(key string) string {
+	return r.Config.Key + "index:" + key
+}
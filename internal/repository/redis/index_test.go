@@ -0,0 +1,90 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"path"
+	"time"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+func (s *TSuite) TestIndexClaim_KeepsExistingCode() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	index := redis.NewIndexRepository(mockRedis, redis.Config{Key: "shortner:"})
+	expireAt := time.Now().Add(time.Hour).Unix()
+	key := "shortner:index:" + repository.IdempotencyKey("", "abc")
+	mockRedis.EXPECT().SetNX(gomock.Any(), key, "new", gomock.Any()).Return(false, nil)
+	mockRedis.EXPECT().Get(gomock.Any(), key).Return("old", nil)
+
+	code, err := index.Claim(context.Background(), repository.IdempotencyKey("", "abc"), "new", expireAt)
+	s.Require().NoError(err)
+	s.Assert().Equal("old", code)
+}
+
+func (s *TSuite) TestIndexClaim_RetriesExpiredEntry() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	index := redis.NewIndexRepository(mockRedis, redis.Config{Key: "shortner:"})
+	gomock.InOrder(
+		mockRedis.EXPECT().SetNX(gomock.Any(), "shortner:index:key", "new", gomock.Any()).Return(false, nil),
+		mockRedis.EXPECT().Get(gomock.Any(), "shortner:index:key").Return("", nil),
+		mockRedis.EXPECT().SetNX(gomock.Any(), "shortner:index:key", "new", gomock.Any()).Return(true, nil),
+	)
+
+	code, err := index.Claim(context.Background(), "key", "new", 0)
+	s.Require().NoError(err)
+	s.Assert().Equal("new", code)
+}
+
+func (s *TSuite) TestIndexLookup_NotFound() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	index := redis.NewIndexRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:index:key").Return("", nil)
+
+	_, err := index.Lookup(context.Background(), "key")
+	s.Assert().Equal(repository.ErrNotFound, err)
+}
+
+func (s *TSuite) TestIndexPut_IdempotencyKeyOutsideLinks() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	index := redis.NewIndexRepository(mockRedis, redis.Config{Key: "shortner:"})
+	// List scans shortner:*:link and reads every key it finds as a link hash
+	mockRedis.EXPECT().Set(gomock.Any(), gomock.Any(), "abc", gomock.Any()).DoAndReturn(func(_ context.Context, key string, _ interface{}, _ time.Duration) error {
+		matched, err := path.Match("shortner:*:link", key)
+		s.Require().NoError(err)
+		s.Assert().False(matched, key)
+		return nil
+	})
+
+	s.Require().NoError(index.Put(context.Background(), repository.IdempotencyKey("", "x:link"), "abc", 0))
+}
+
+func (s *TSuite) TestIndexPutBatch_OnePipeline() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	index := redis.NewIndexRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().SetBatch(gomock.Any(), []redis.StringEntry{
+		{Key: "shortner:index:first", Value: "abc", Exp: 0},
+		{Key: "shortner:index:second", Value: "def", Exp: 0},
+	}).Return(nil)
+
+	s.Require().NoError(index.PutBatch(context.Background(), []repository.IndexEntry{
+		{Key: "first", Code: "abc"},
+		{Key: "second", Code: "def"},
+	}))
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"url-shortener/internal/repository"
+)
+
+// KeyRepository keeps every API key as one `{Key}apikey:{id}` hash without TTL
+type KeyRepository struct {
+	Handler HandlerInterface
+	Config  Config
+}
+
+func NewKeyRepository(handler HandlerInterface, config Config) *KeyRepository {
+	return &KeyRepository{
+		Handler: handler,
+		Config:  config,
+	}
+}
+
+func (r *KeyRepository) CreateKey(ctx context.Context, key repository.APIKey) error {
+	created, err := r.Handler.HMSetNX(ctx, r.key(key.ID), map[string]interface{}{
+		"tenant":  key.Tenant,
+		"hash":    key.Hash,
+		"scopes":  strings.Join(key.Scopes, ","),
+		"created": key.CreatedAt,
+	}, 0)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("api key %v already exists", key.ID)
+	}
+	return nil
+}
+
+func (r *KeyRepository) GetKey(ctx context.Context, id string) (*repository.APIKey, error) {
+	fields, err := r.Handler.HGetAll(ctx, r.key(id))
+	if err != nil {
+		return nil, err
+	}
+	if fields["hash"] == "" {
+		return nil, repository.ErrNotFound
+	}
+	return toKey(id, fields), nil
+}
+
+func (r *KeyRepository) ListKeys(ctx context.Context) ([]repository.APIKey, error) {
+	keys := []repository.APIKey{}
+	var cursor uint64
+	for {
+		names, next, err := r.Handler.Scan(ctx, cursor, escapeGlob(r.key(""))+"*", 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			fields, err := r.Handler.HGetAll(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if fields["hash"] == "" {
+				continue
+			}
+			keys = append(keys, *toKey(strings.TrimPrefix(name, r.key("")), fields))
+		}
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *KeyRepository) DeleteKey(ctx context.Context, id string) error {
+	deleted, err := r.Handler.Del(ctx, []string{r.key(id)})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *KeyRepository) key(id string) string {
+	return r.Config.Key + "apikey:" + id
+}
+
+func toKey(id string, fields map[string]string) *repository.APIKey {
+	created, _ := strconv.ParseInt(fields["created"], 0, 64)
+	return &repository.APIKey{
+		ID:        id,
+		Tenant:    fields["tenant"],
+		Hash:      fields["hash"],
+		Scopes:    strings.Split(fields["scopes"], ","),
+		CreatedAt: created,
+	}
+}
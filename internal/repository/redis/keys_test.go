@@ -0,0 +1,58 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"time"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+func (s *TSuite) TestKeys_CreateAndGet() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	keys := redis.NewKeyRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().HMSetNX(gomock.Any(), "shortner:apikey:k1", map[string]interface{}{
+		"tenant":  "acme",
+		"hash":    "hash",
+		"scopes":  "create,delete",
+		"created": int64(1619766384),
+	}, time.Duration(0)).Return(true, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:apikey:k1").
+		Return(map[string]string{"tenant": "acme", "hash": "hash", "scopes": "create,delete", "created": "1619766384"}, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:apikey:k2").Return(map[string]string{}, nil)
+
+	key := repository.APIKey{ID: "k1", Tenant: "acme", Hash: "hash", Scopes: []string{"create", "delete"}, CreatedAt: 1619766384}
+	s.Require().NoError(keys.CreateKey(context.Background(), key))
+	got, err := keys.GetKey(context.Background(), "k1")
+	s.Require().NoError(err)
+	s.Assert().Equal(&key, got)
+	_, err = keys.GetKey(context.Background(), "k2")
+	s.Assert().Equal(repository.ErrNotFound, err)
+}
+
+func (s *TSuite) TestKeys_ListSortedAndDelete() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	keys := redis.NewKeyRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), "shortner:apikey:*", int64(100)).
+		Return([]string{"shortner:apikey:k2", "shortner:apikey:k1"}, uint64(0), nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:apikey:k2").Return(map[string]string{"hash": "h2", "scopes": "admin"}, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:apikey:k1").Return(map[string]string{"hash": "h1", "scopes": "create"}, nil)
+	mockRedis.EXPECT().Del(gomock.Any(), []string{"shortner:apikey:k1"}).Return(int64(1), nil)
+	mockRedis.EXPECT().Del(gomock.Any(), []string{"shortner:apikey:k3"}).Return(int64(0), nil)
+
+	list, err := keys.ListKeys(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(list, 2)
+	s.Assert().Equal("k1", list[0].ID)
+	s.Assert().Equal([]string{"admin"}, list[1].Scopes)
+
+	s.Assert().NoError(keys.DeleteKey(context.Background(), "k1"))
+	s.Assert().Equal(repository.ErrNotFound, keys.DeleteKey(context.Background(), "k3"))
+}
@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+// LinkRepository stores every link as one `{Key}{code}:link` hash expiring with the link,
+// deleted links leave a `{Key}{code}:deleted` tombstone for Retention days.
+type LinkRepository struct {
+	Handler HandlerInterface
+	Config  Config
+	now     func() time.Time
+}
+
+func NewLinkRepository(handler HandlerInterface, config Config) *LinkRepository {
+	return &LinkRepository{
+		Handler: handler,
+		Config:  config,
+		now:     time.Now,
+	}
+}
+
+func (r *LinkRepository) Create(ctx context.Context, link repository.Link) (bool, error) {
+	entry := r.entry(link)
+	return r.Handler.HMSetNX(ctx, entry.Key, entry.Fields, entry.Exp)
+}
+
+func (r *LinkRepository) CreateBatch(ctx context.Context, links []repository.Link) ([]bool, error) {
+	entries := make([]HashEntry, 0, len(links))
+	for _, link := range links {
+		entries = append(entries, r.entry(link))
+	}
+	return r.Handler.HMSetNXBatch(ctx, entries)
+}
+
+func (r *LinkRepository) Update(ctx context.Context, link repository.Link) error {
+	fields := map[string]interface{}{
+		"full":   link.FullURL,
+		"expire": link.ExpireAt,
+		"hits":   link.MaxHits,
+	}
+	version, err := r.Handler.HMSetVersion(ctx, r.key(link.Code, "link"), fields, link.Version, r.Config.TTL(link.ExpireAt, r.now()))
+	if err != nil {
+		return err
+	}
+
+	switch version {
+	case 0:
+		// the link is gone, Get tells a deleted link from an unknown one
+		_, err = r.Get(ctx, link.Code)
+		if err == nil {
+			// recreated meanwhile, its version can not be the one asked for
+			return repository.ErrVersionMismatch
+		}
+		return err
+	case -1:
+		return repository.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (r *LinkRepository) Get(ctx context.Context, code string) (*repository.Link, error) {
+	fields, err := r.Handler.HGetAll(ctx, r.key(code, "link"))
+	if err != nil {
+		return nil, err
+	}
+
+	if fields["full"] == "" {
+		deleted, err := r.Handler.Get(ctx, r.key(code, "deleted"))
+		if err != nil {
+			return nil, err
+		}
+		if deleted != "" {
+			return nil, repository.ErrDeleted
+		}
+		return nil, repository.ErrNotFound
+	}
+
+	return toLink(code, fields), nil
+}
+
+// Delete replaces the link with its tombstone in one script. Like expired links, deleted links answer 410
+// for Retention days, then the tombstone expires and the code is unknown again.
+func (r *LinkRepository) Delete(ctx context.Context, code string) error {
+	retention := time.Duration(r.Config.Retention) * day
+	deleted, err := r.Handler.HDelTombstone(ctx, r.key(code, "link"), "full", r.key(code, "deleted"), r.now().Unix(), retention)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// List scans the keyspace until the page is filled or the keyspace is exhausted, the cursor is the SCAN cursor.
+// A page may hold a few more links than filter.Limit.
+func (r *LinkRepository) List(ctx context.Context, filter repository.ListFilter) ([]repository.Link, string, error) {
+	var cursor uint64
+	if filter.Cursor != "" {
+		value, err := strconv.ParseUint(filter.Cursor, 10, 64)
+		if err != nil {
+			return nil, "", repository.ErrInvalidCursor
+		}
+		cursor = value
+	}
+
+	match := fmt.Sprintf("%v%v*:%v", escapeGlob(r.Config.Key), escapeGlob(filter.CodePrefix), "link")
+	keyword := strings.ToLower(filter.Keyword)
+
+	links := make([]repository.Link, 0, filter.Limit)
+	for {
+		keys, next, err := r.Handler.Scan(ctx, cursor, match, int64(filter.Limit))
+		if err != nil {
+			return nil, "", err
+		}
+		cursor = next
+
+		for _, key := range keys {
+			fields, err := r.Handler.HGetAll(ctx, key)
+			if err != nil {
+				return nil, "", err
+			}
+			// the link may have expired between SCAN and HGETALL
+			if fields["full"] == "" {
+				continue
+			}
+			if keyword != "" && !strings.Contains(strings.ToLower(fields["full"]), keyword) {
+				continue
+			}
+			if filter.Owner != "" && fields["owner"] != filter.Owner {
+				continue
+			}
+			code := strings.TrimSuffix(strings.TrimPrefix(key, r.Config.Key), ":link")
+			links = append(links, *toLink(code, fields))
+		}
+
+		if cursor == 0 {
+			return links, "", nil
+		}
+		if len(links) >= filter.Limit {
+			return links, strconv.FormatUint(cursor, 10), nil
+		}
+	}
+}
+
+func (r *LinkRepository) IncrementHits(ctx context.Context, code string) (int64, error) {
+	// the quota is stored in the hits field, the visits in count
+	hits, err := r.Handler.HIncrByMax(ctx, r.key(code, "link"), "count", "hits")
+	if err != nil {
+		return 0, err
+	}
+	switch hits {
+	case 0:
+		// HIncrByMax never recreates a link that expired or was deleted meanwhile
+		return 0, repository.ErrNotFound
+	case -1:
+		return 0, repository.ErrHitsExceeded
+	}
+	return hits, nil
+}
+
+func (r *LinkRepository) Flag(ctx context.Context, code string, threat string) error {
+	exists, err := r.Handler.HSetXX(ctx, r.key(code, "link"), "flagged", threat)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// entry is the hash a new link is stored as, public links have no password field
+// and links redirecting with 302 no status field, links without owner no owner field
+func (r *LinkRepository) entry(link repository.Link) HashEntry {
+	entry := HashEntry{
+		Key: r.key(link.Code, "link"),
+		Fields: map[string]interface{}{
+			"full":    link.FullURL,
+			"expire":  link.ExpireAt,
+			"hits":    link.MaxHits,
+			"count":   0,
+			"created": link.CreatedAt,
+			"version": 1,
+		},
+		Exp: r.Config.TTL(link.ExpireAt, r.now()),
+	}
+	if link.PasswordHash != "" {
+		entry.Fields["password"] = link.PasswordHash
+	}
+	if link.RedirectStatus != 0 {
+		entry.Fields["status"] = link.RedirectStatus
+	}
+	if link.Owner != "" {
+		entry.Fields["owner"] = link.Owner
+	}
+	return entry
+}
+
+func (r *LinkRepository) key(code string, suffix string) string {
+	return fmt.Sprintf("%v%v:%v", r.Config.Key, code, suffix)
+}
+
+func toLink(code string, fields map[string]string) *repository.Link {
+	expire, _ := strconv.ParseInt(fields["expire"], 0, 64)
+	maxHits, _ := strconv.ParseInt(fields["hits"], 0, 64)
+	hits, _ := strconv.ParseInt(fields["count"], 0, 64)
+	created, _ := strconv.ParseInt(fields["created"], 0, 64)
+	status, _ := strconv.Atoi(fields["status"])
+	// links stored before versioning are at version 1
+	version, err := strconv.ParseInt(fields["version"], 0, 64)
+	if err != nil {
+		version = 1
+	}
+
+	return &repository.Link{
+		Code:      code,
+		FullURL:   fields["full"],
+		ExpireAt:  expire,
+		MaxHits:   maxHits,
+		Hits:      hits,
+		CreatedAt: created,
+		Version:   version,
+
+		PasswordHash:   fields["password"],
+		RedirectStatus: status,
+		Owner:          fields["owner"],
+		Flagged:        fields["flagged"],
+	}
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as a pattern
+func escapeGlob(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
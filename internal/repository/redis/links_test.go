@@ -0,0 +1,275 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+type TSuite struct {
+	suite.Suite
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(TSuite))
+}
+
+var (
+	mockRedis *mockredis.MockHandlerInterface
+)
+
+func setUpRepositoryMocking(ctrl *gomock.Controller) *redis.LinkRepository {
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+
+	return redis.NewLinkRepository(mockRedis, redis.Config{Key: "shortner:", Retention: 7})
+}
+
+func (s *TSuite) TestCreate_ExpiresWithLink() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HMSetNX(gomock.Any(), "shortner:abc:link", map[string]interface{}{
+		"full":    "https://www.speedtest.net",
+		"expire":  int64(4102444800),
+		"hits":    int64(10),
+		"count":   0,
+		"created": int64(1619766384),
+		"version": 1,
+	}, gomock.Any()).DoAndReturn(func(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (bool, error) {
+		// the key outlives the link by the retention
+		s.Assert().True(exp > time.Until(time.Unix(4102444800, 0)))
+		return true, nil
+	})
+
+	created, err := links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, CreatedAt: 1619766384})
+	s.Require().NoError(err)
+	s.Assert().True(created)
+}
+
+func (s *TSuite) TestGet_Link() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").
+		Return(map[string]string{"full": "https://www.speedtest.net", "expire": "4102444800", "hits": "10", "count": "3", "version": "4"}, nil)
+
+	link, err := links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(&repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", ExpireAt: 4102444800, MaxHits: 10, Hits: 3, Version: 4}, link)
+}
+
+func (s *TSuite) TestCreate_PasswordHash() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HMSetNX(gomock.Any(), "shortner:abc:link", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (bool, error) {
+			s.Assert().Equal("$2a$10$hash", fields["password"])
+			return true, nil
+		})
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").
+		Return(map[string]string{"full": "https://www.speedtest.net", "password": "$2a$10$hash"}, nil)
+
+	created, err := links.Create(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.speedtest.net", PasswordHash: "$2a$10$hash"})
+	s.Require().NoError(err)
+	s.Assert().True(created)
+
+	link, err := links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("$2a$10$hash", link.PasswordHash)
+}
+
+func (s *TSuite) TestGet_NotFoundOrDeleted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), gomock.Any()).Return(map[string]string{}, nil).Times(2)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:abc:deleted").Return("", nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:def:deleted").Return("1619766384", nil)
+
+	_, err := links.Get(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+	_, err = links.Get(context.Background(), "def")
+	s.Assert().Equal(repository.ErrDeleted, err)
+}
+
+func (s *TSuite) TestDelete_TombstoneExpires() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	links := redis.NewLinkRepository(mockRedis, redis.Config{Key: "shortner:", Retention: 7})
+	mockRedis.EXPECT().HDelTombstone(gomock.Any(), "shortner:abc:link", "full", "shortner:abc:deleted", gomock.Any(), 7*24*time.Hour).Return(true, nil)
+
+	s.Assert().NoError(links.Delete(context.Background(), "abc"))
+}
+
+func (s *TSuite) TestDelete_NotFound() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HDelTombstone(gomock.Any(), "shortner:abc:link", "full", "shortner:abc:deleted", gomock.Any(), gomock.Any()).Return(false, nil)
+
+	s.Assert().Equal(repository.ErrNotFound, links.Delete(context.Background(), "abc"))
+}
+
+func (s *TSuite) TestList_FilterByCodeAndKeyword() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(7), "shortner:ab*:link", int64(2)).
+		Return([]string{"shortner:abc:link", "shortner:abd:link", "shortner:abe:link"}, uint64(0), nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").
+		Return(map[string]string{"full": "https://www.SpeedTest.net", "count": "3"}, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abd:link").Return(map[string]string{"full": "https://www.example.com"}, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abe:link").Return(map[string]string{}, nil)
+
+	page, cursor, err := links.List(context.Background(), repository.ListFilter{Cursor: "7", Limit: 2, CodePrefix: "ab", Keyword: "speedtest"})
+	s.Require().NoError(err)
+	// links stored before versioning are at version 1
+	s.Assert().Equal([]repository.Link{{Code: "abc", FullURL: "https://www.SpeedTest.net", Hits: 3, Version: 1}}, page)
+	s.Assert().Equal("", cursor)
+}
+
+func (s *TSuite) TestList_ContinueScanUntilPageFilled() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), "shortner:*:link", int64(1)).Return([]string{}, uint64(12), nil)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(12), "shortner:*:link", int64(1)).
+		Return([]string{"shortner:xyz:link"}, uint64(34), nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:xyz:link").Return(map[string]string{"full": "https://www.example.com"}, nil)
+
+	page, cursor, err := links.List(context.Background(), repository.ListFilter{Limit: 1})
+	s.Require().NoError(err)
+	s.Assert().Len(page, 1)
+	s.Assert().Equal("34", cursor)
+}
+
+func (s *TSuite) TestList_InvalidCursor() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+
+	_, _, err := links.List(context.Background(), repository.ListFilter{Cursor: "abc", Limit: 1})
+	s.Assert().Equal(repository.ErrInvalidCursor, err)
+}
+
+func (s *TSuite) TestIncrementHits_Reclaimed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HIncrByMax(gomock.Any(), "shortner:abc:link", "count", "hits").Return(int64(0), nil)
+
+	_, err := links.IncrementHits(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrNotFound, err)
+}
+
+func (s *TSuite) TestIncrementHits_QuotaExhausted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HIncrByMax(gomock.Any(), "shortner:abc:link", "count", "hits").Return(int64(-1), nil)
+
+	_, err := links.IncrementHits(context.Background(), "abc")
+	s.Assert().Equal(repository.ErrHitsExceeded, err)
+}
+
+func (s *TSuite) TestFlag() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HSetXX(gomock.Any(), "shortner:abc:link", "flagged", "MALWARE").Return(true, nil)
+	mockRedis.EXPECT().HSetXX(gomock.Any(), "shortner:def:link", "flagged", "MALWARE").Return(false, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").Return(map[string]string{"full": "https://www.speedtest.net", "flagged": "MALWARE"}, nil)
+
+	s.Require().NoError(links.Flag(context.Background(), "abc", "MALWARE"))
+	s.Assert().Equal(repository.ErrNotFound, links.Flag(context.Background(), "def", "MALWARE"))
+
+	link, err := links.Get(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal("MALWARE", link.Flagged)
+}
+
+func (s *TSuite) TestCreateBatch_Pipelined() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HMSetNXBatch(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entries []redis.HashEntry) ([]bool, error) {
+		s.Require().Len(entries, 2)
+		s.Assert().Equal("shortner:abc:link", entries[0].Key)
+		s.Assert().Equal("https://www.speedtest.net", entries[0].Fields["full"])
+		s.Assert().Equal("shortner:def:link", entries[1].Key)
+		return []bool{true, false}, nil
+	})
+
+	created, err := links.CreateBatch(context.Background(), []repository.Link{
+		{Code: "abc", FullURL: "https://www.speedtest.net"},
+		{Code: "def", FullURL: "https://www.example.com"},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]bool{true, false}, created)
+}
+
+func (s *TSuite) TestUpdate_Versioned() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	fields := map[string]interface{}{
+		"full":   "https://www.example.com",
+		"expire": int64(0),
+		"hits":   int64(20),
+	}
+	mockRedis.EXPECT().HMSetVersion(gomock.Any(), "shortner:abc:link", fields, int64(2), gomock.Any()).Return(int64(3), nil)
+	mockRedis.EXPECT().HMSetVersion(gomock.Any(), "shortner:abc:link", fields, int64(1), gomock.Any()).Return(int64(-1), nil)
+
+	link := repository.Link{Code: "abc", FullURL: "https://www.example.com", MaxHits: 20, Version: 2}
+	s.Assert().NoError(links.Update(context.Background(), link))
+	link.Version = 1
+	s.Assert().Equal(repository.ErrVersionMismatch, links.Update(context.Background(), link))
+}
+
+func (s *TSuite) TestUpdate_Deleted() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().HMSetVersion(gomock.Any(), "shortner:abc:link", gomock.Any(), int64(0), gomock.Any()).Return(int64(0), nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").Return(map[string]string{}, nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:abc:deleted").Return("1619766384", nil)
+
+	s.Assert().Equal(repository.ErrDeleted, links.Update(context.Background(), repository.Link{Code: "abc", FullURL: "https://www.example.com"}))
+}
+
+func (s *TSuite) TestList_FilterByOwner() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	links := setUpRepositoryMocking(ctrl)
+	mockRedis.EXPECT().Scan(gomock.Any(), uint64(0), "shortner:*:link", int64(10)).
+		Return([]string{"shortner:abc:link", "shortner:abd:link"}, uint64(0), nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:link").Return(map[string]string{"full": "https://www.speedtest.net", "owner": "acme"}, nil)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abd:link").Return(map[string]string{"full": "https://www.example.com"}, nil)
+
+	page, _, err := links.List(context.Background(), repository.ListFilter{Limit: 10, Owner: "acme"})
+	s.Require().NoError(err)
+	s.Assert().Equal([]repository.Link{{Code: "abc", FullURL: "https://www.speedtest.net", Version: 1, Owner: "acme"}}, page)
+}
@@ -5,8 +5,8 @@
 package mock_redis
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
-	newrelic "github.com/newrelic/go-agent/v3/newrelic"
 	reflect "reflect"
 	time "time"
 	"url-shortener/internal/repository/redis"
@@ -61,45 +61,328 @@ func (mr *MockHandlerInterfaceMockRecorder) Disconnect() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockHandlerInterface)(nil).Disconnect))
 }
 
+// Ping mocks base method
+func (m *MockHandlerInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping
+func (mr *MockHandlerInterfaceMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockHandlerInterface)(nil).Ping), ctx)
+}
+
 // Set mocks base method
-func (m *MockHandlerInterface) Set(key string, value interface{}, exp time.Duration, txn *newrelic.Transaction) error {
+func (m *MockHandlerInterface) Set(ctx context.Context, key string, value interface{}, exp time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", key, value, exp, txn)
+	ret := m.ctrl.Call(m, "Set", ctx, key, value, exp)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set
-func (mr *MockHandlerInterfaceMockRecorder) Set(key, value, exp, txn interface{}) *gomock.Call {
+func (mr *MockHandlerInterfaceMockRecorder) Set(ctx, key, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockHandlerInterface)(nil).Set), ctx, key, value, exp)
+}
+
+// SetBatch mocks base method
+func (m *MockHandlerInterface) SetBatch(ctx context.Context, entries []redis.StringEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBatch", ctx, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBatch indicates an expected call of SetBatch
+func (mr *MockHandlerInterfaceMockRecorder) SetBatch(ctx, entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBatch", reflect.TypeOf((*MockHandlerInterface)(nil).SetBatch), ctx, entries)
+}
+
+// SetNX mocks base method
+func (m *MockHandlerInterface) SetNX(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, value, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX
+func (mr *MockHandlerInterfaceMockRecorder) SetNX(ctx, key, value, exp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockHandlerInterface)(nil).Set), key, value, exp, txn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockHandlerInterface)(nil).SetNX), ctx, key, value, exp)
 }
 
 // Get mocks base method
-func (m *MockHandlerInterface) Get(key string, txn *newrelic.Transaction) (string, error) {
+func (m *MockHandlerInterface) Get(ctx context.Context, key string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", key, txn)
+	ret := m.ctrl.Call(m, "Get", ctx, key)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get
-func (mr *MockHandlerInterfaceMockRecorder) Get(key, txn interface{}) *gomock.Call {
+func (mr *MockHandlerInterfaceMockRecorder) Get(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockHandlerInterface)(nil).Get), key, txn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockHandlerInterface)(nil).Get), ctx, key)
 }
 
 // Del mocks base method
-func (m *MockHandlerInterface) Del(key string, txn *newrelic.Transaction) error {
+func (m *MockHandlerInterface) Del(ctx context.Context, keys []string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Del", key, txn)
+	ret := m.ctrl.Call(m, "Del", ctx, keys)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Del indicates an expected call of Del
+func (mr *MockHandlerInterfaceMockRecorder) Del(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockHandlerInterface)(nil).Del), ctx, keys)
+}
+
+// Incr mocks base method
+func (m *MockHandlerInterface) Incr(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr
+func (mr *MockHandlerInterfaceMockRecorder) Incr(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockHandlerInterface)(nil).Incr), ctx, key)
+}
+
+// IncrBy mocks base method
+func (m *MockHandlerInterface) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrBy", ctx, key, n)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrBy indicates an expected call of IncrBy
+func (mr *MockHandlerInterfaceMockRecorder) IncrBy(ctx, key, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrBy", reflect.TypeOf((*MockHandlerInterface)(nil).IncrBy), ctx, key, n)
+}
+
+// Scan mocks base method
+func (m *MockHandlerInterface) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, cursor, match, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(uint64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Scan indicates an expected call of Scan
+func (mr *MockHandlerInterfaceMockRecorder) Scan(ctx, cursor, match, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockHandlerInterface)(nil).Scan), ctx, cursor, match, count)
+}
+
+// HMSet mocks base method
+func (m *MockHandlerInterface) HMSet(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HMSet", ctx, key, fields, exp)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Del indicates an expected call of Del
-func (mr *MockHandlerInterfaceMockRecorder) Del(key, txn interface{}) *gomock.Call {
+// HMSet indicates an expected call of HMSet
+func (mr *MockHandlerInterfaceMockRecorder) HMSet(ctx, key, fields, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HMSet", reflect.TypeOf((*MockHandlerInterface)(nil).HMSet), ctx, key, fields, exp)
+}
+
+// HMSetNX mocks base method
+func (m *MockHandlerInterface) HMSetNX(ctx context.Context, key string, fields map[string]interface{}, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HMSetNX", ctx, key, fields, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HMSetNX indicates an expected call of HMSetNX
+func (mr *MockHandlerInterfaceMockRecorder) HMSetNX(ctx, key, fields, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HMSetNX", reflect.TypeOf((*MockHandlerInterface)(nil).HMSetNX), ctx, key, fields, exp)
+}
+
+// HMSetVersion mocks base method
+func (m *MockHandlerInterface) HMSetVersion(ctx context.Context, key string, fields map[string]interface{}, version int64, exp time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HMSetVersion", ctx, key, fields, version, exp)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HMSetVersion indicates an expected call of HMSetVersion
+func (mr *MockHandlerInterfaceMockRecorder) HMSetVersion(ctx, key, fields, version, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HMSetVersion", reflect.TypeOf((*MockHandlerInterface)(nil).HMSetVersion), ctx, key, fields, version, exp)
+}
+
+// HGetAll mocks base method
+func (m *MockHandlerInterface) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", ctx, key)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll
+func (mr *MockHandlerInterfaceMockRecorder) HGetAll(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockHandlerInterface)(nil).HGetAll), ctx, key)
+}
+
+// SAdd mocks base method
+func (m *MockHandlerInterface) SAdd(ctx context.Context, key, member string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SAdd", ctx, key, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SAdd indicates an expected call of SAdd
+func (mr *MockHandlerInterfaceMockRecorder) SAdd(ctx, key, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockHandlerInterface)(nil).SAdd), ctx, key, member)
+}
+
+// SRem mocks base method
+func (m *MockHandlerInterface) SRem(ctx context.Context, key, member string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SRem", ctx, key, member)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SRem indicates an expected call of SRem
+func (mr *MockHandlerInterfaceMockRecorder) SRem(ctx, key, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRem", reflect.TypeOf((*MockHandlerInterface)(nil).SRem), ctx, key, member)
+}
+
+// SMembers mocks base method
+func (m *MockHandlerInterface) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", ctx, key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers
+func (mr *MockHandlerInterfaceMockRecorder) SMembers(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockHandlerInterface)(nil).SMembers), ctx, key)
+}
+
+// HIncrByMax mocks base method
+func (m *MockHandlerInterface) HIncrByMax(ctx context.Context, key, field, maxField string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HIncrByMax", ctx, key, field, maxField)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HIncrByMax indicates an expected call of HIncrByMax
+func (mr *MockHandlerInterfaceMockRecorder) HIncrByMax(ctx, key, field, maxField interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HIncrByMax", reflect.TypeOf((*MockHandlerInterface)(nil).HIncrByMax), ctx, key, field, maxField)
+}
+
+// HSetXX mocks base method
+func (m *MockHandlerInterface) HSetXX(ctx context.Context, key, field string, value interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSetXX", ctx, key, field, value)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HSetXX indicates an expected call of HSetXX
+func (mr *MockHandlerInterfaceMockRecorder) HSetXX(ctx, key, field, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSetXX", reflect.TypeOf((*MockHandlerInterface)(nil).HSetXX), ctx, key, field, value)
+}
+
+// HDelTombstone mocks base method
+func (m *MockHandlerInterface) HDelTombstone(ctx context.Context, key, field, tombstone string, value interface{}, exp time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HDelTombstone", ctx, key, field, tombstone, value, exp)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HDelTombstone indicates an expected call of HDelTombstone
+func (mr *MockHandlerInterfaceMockRecorder) HDelTombstone(ctx, key, field, tombstone, value, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDelTombstone", reflect.TypeOf((*MockHandlerInterface)(nil).HDelTombstone), ctx, key, field, tombstone, value, exp)
+}
+
+// HIncrByFields mocks base method
+func (m *MockHandlerInterface) HIncrByFields(ctx context.Context, key string, fields map[string]int64, exp time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HIncrByFields", ctx, key, fields, exp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HIncrByFields indicates an expected call of HIncrByFields
+func (mr *MockHandlerInterfaceMockRecorder) HIncrByFields(ctx, key, fields, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HIncrByFields", reflect.TypeOf((*MockHandlerInterface)(nil).HIncrByFields), ctx, key, fields, exp)
+}
+
+// HMSetNXBatch mocks base method
+func (m *MockHandlerInterface) HMSetNXBatch(ctx context.Context, entries []redis.HashEntry) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HMSetNXBatch", ctx, entries)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HMSetNXBatch indicates an expected call of HMSetNXBatch
+func (mr *MockHandlerInterfaceMockRecorder) HMSetNXBatch(ctx, entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HMSetNXBatch", reflect.TypeOf((*MockHandlerInterface)(nil).HMSetNXBatch), ctx, entries)
+}
+
+// IncrWindow mocks base method
+func (m *MockHandlerInterface) IncrWindow(ctx context.Context, key, previous string, exp time.Duration) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrWindow", ctx, key, previous, exp)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IncrWindow indicates an expected call of IncrWindow
+func (mr *MockHandlerInterfaceMockRecorder) IncrWindow(ctx, key, previous, exp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockHandlerInterface)(nil).Del), key, txn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrWindow", reflect.TypeOf((*MockHandlerInterface)(nil).IncrWindow), ctx, key, previous, exp)
 }
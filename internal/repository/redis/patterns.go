@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"url-shortener/internal/repository"
+)
+
+// PatternRepository keeps the runtime blacklist patterns in the `{Key}blacklist` set without TTL
+type PatternRepository struct {
+	Handler HandlerInterface
+	Config  Config
+}
+
+func NewPatternRepository(handler HandlerInterface, config Config) *PatternRepository {
+	return &PatternRepository{
+		Handler: handler,
+		Config:  config,
+	}
+}
+
+func (r *PatternRepository) AddPattern(ctx context.Context, pattern string) error {
+	return r.Handler.SAdd(ctx, r.key(), pattern)
+}
+
+func (r *PatternRepository) RemovePattern(ctx context.Context, pattern string) error {
+	removed, err := r.Handler.SRem(ctx, r.key(), pattern)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListPatterns returns the patterns sorted, a set has no order
+func (r *PatternRepository) ListPatterns(ctx context.Context) ([]string, error) {
+	patterns, err := r.Handler.SMembers(ctx, r.key())
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(patterns)
+	return patterns, nil
+}
+
+func (r *PatternRepository) key() string {
+	return r.Config.Key + "blacklist"
+}
@@ -0,0 +1,28 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+func (s *TSuite) TestPatterns_AddListRemove() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	patterns := redis.NewPatternRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().SAdd(gomock.Any(), "shortner:blacklist", `spam\.example`).Return(nil)
+	mockRedis.EXPECT().SMembers(gomock.Any(), "shortner:blacklist").Return([]string{`spam\.example`, `phishing\.example`}, nil)
+	mockRedis.EXPECT().SRem(gomock.Any(), "shortner:blacklist", `spam\.example`).Return(true, nil)
+	mockRedis.EXPECT().SRem(gomock.Any(), "shortner:blacklist", "unknown").Return(false, nil)
+
+	s.Require().NoError(patterns.AddPattern(context.Background(), `spam\.example`))
+	list, err := patterns.ListPatterns(context.Background())
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{`phishing\.example`, `spam\.example`}, list)
+	s.Require().NoError(patterns.RemovePattern(context.Background(), `spam\.example`))
+	s.Assert().Equal(repository.ErrNotFound, patterns.RemovePattern(context.Background(), "unknown"))
+}
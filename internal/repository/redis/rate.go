@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RateRepository keeps the rate limit counter of every window in a `{Key}rate:{key}:{start}` string.
+// A counter lives two windows so the next window still reads it as its previous one.
+type RateRepository struct {
+	Handler HandlerInterface
+	Config  Config
+}
+
+func NewRateRepository(handler HandlerInterface, config Config) *RateRepository {
+	return &RateRepository{
+		Handler: handler,
+		Config:  config,
+	}
+}
+
+// IncrWindow implements ratelimit.Store
+func (r *RateRepository) IncrWindow(ctx context.Context, key string, start time.Time, window time.Duration) (int64, int64, error) {
+	return r.Handler.IncrWindow(ctx, r.key(key, start), r.key(key, start.Add(-window)), 2*window)
+}
+
+// Hits implements ratelimit.Store
+func (r *RateRepository) Hits(ctx context.Context, key string, start time.Time) (int64, error) {
+	value, err := r.Handler.Get(ctx, r.key(key, start))
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (r *RateRepository) key(key string, start time.Time) string {
+	return fmt.Sprintf("%vrate:%v:%v", r.Config.Key, key, start.Unix())
+}
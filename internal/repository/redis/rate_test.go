@@ -0,0 +1,42 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"time"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+func (s *TSuite) TestRateIncrWindow_ReadsPreviousWindow() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	rates := redis.NewRateRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().IncrWindow(gomock.Any(), "shortner:rate:generate:ip:10.0.0.1:1619766360", "shortner:rate:generate:ip:10.0.0.1:1619766300", 2*time.Minute).
+		Return(int64(3), int64(7), nil)
+
+	current, previous, err := rates.IncrWindow(context.Background(), "generate:ip:10.0.0.1", time.Unix(1619766360, 0), time.Minute)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(3), current)
+	s.Assert().Equal(int64(7), previous)
+}
+
+func (s *TSuite) TestRateHits_ReadsWithoutCounting() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	rates := redis.NewRateRepository(mockRedis, redis.Config{Key: "shortner:"})
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:rate:unlock:abc:ip:10.0.0.1:1619766360").Return("4", nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "shortner:rate:unlock:def:ip:10.0.0.1:1619766360").Return("", nil)
+
+	hits, err := rates.Hits(context.Background(), "unlock:abc:ip:10.0.0.1", time.Unix(1619766360, 0))
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(4), hits)
+
+	hits, err = rates.Hits(context.Background(), "unlock:def:ip:10.0.0.1", time.Unix(1619766360, 0))
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(0), hits)
+}
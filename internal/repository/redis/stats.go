@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+const (
+	dayField      = "day:"
+	referrerField = "referrer:"
+	countryField  = "country:"
+)
+
+// StatsRepository keeps time-bucketed click counters in one `{Key}{code}:stats` hash per link.
+// The hash expires Retention after the last click.
+type StatsRepository struct {
+	Handler   HandlerInterface
+	Config    Config
+	Retention time.Duration
+}
+
+func NewStatsRepository(handler HandlerInterface, config Config, retention time.Duration) *StatsRepository {
+	return &StatsRepository{
+		Handler:   handler,
+		Config:    config,
+		Retention: retention,
+	}
+}
+
+func (r *StatsRepository) RecordClick(ctx context.Context, click repository.Click) error {
+	fields := map[string]int64{
+		"total":                        1,
+		dayField + click.Day:           1,
+		referrerField + click.Referrer: 1,
+		countryField + click.Country:   1,
+	}
+	return r.Handler.HIncrByFields(ctx, r.key(click.Code), fields, r.Retention)
+}
+
+func (r *StatsRepository) Stats(ctx context.Context, code string) (*repository.LinkStats, error) {
+	fields, err := r.Handler.HGetAll(ctx, r.key(code))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := repository.NewLinkStats()
+	for field, value := range fields {
+		count, _ := strconv.ParseInt(value, 10, 64)
+		switch {
+		case field == "total":
+			stats.Total = count
+		case strings.HasPrefix(field, dayField):
+			stats.Days[strings.TrimPrefix(field, dayField)] = count
+		case strings.HasPrefix(field, referrerField):
+			stats.Referrers[strings.TrimPrefix(field, referrerField)] = count
+		case strings.HasPrefix(field, countryField):
+			stats.Countries[strings.TrimPrefix(field, countryField)] = count
+		}
+	}
+	return stats, nil
+}
+
+func (r *StatsRepository) key(code string) string {
+	return fmt.Sprintf("%v%v:%v", r.Config.Key, code, "stats")
+}
@@ -0,0 +1,51 @@
+package redis_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"time"
+	"url-shortener/internal/repository"
+	"url-shortener/internal/repository/redis"
+	mockredis "url-shortener/internal/repository/redis/mocks"
+)
+
+func (s *TSuite) TestRecordClick_Buckets() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	stats := redis.NewStatsRepository(mockRedis, redis.Config{Key: "shortner:"}, time.Hour)
+	mockRedis.EXPECT().HIncrByFields(gomock.Any(), "shortner:abc:stats", map[string]int64{
+		"total":                     1,
+		"day:2021-04-30":            1,
+		"referrer:news.example.com": 1,
+		"country:TH":                1,
+	}, time.Hour).Return(nil)
+
+	s.Assert().NoError(stats.RecordClick(context.Background(), repository.Click{Code: "abc", Day: "2021-04-30", Referrer: "news.example.com", Country: "TH"}))
+}
+
+func (s *TSuite) TestStats_Breakdown() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	mockRedis = mockredis.NewMockHandlerInterface(ctrl)
+	stats := redis.NewStatsRepository(mockRedis, redis.Config{Key: "shortner:"}, time.Hour)
+	mockRedis.EXPECT().HGetAll(gomock.Any(), "shortner:abc:stats").Return(map[string]string{
+		"total":              "3",
+		"day:2021-04-29":     "1",
+		"day:2021-04-30":     "2",
+		"referrer:direct":    "3",
+		"country:unknown":    "3",
+		"referrer:a:b.com:1": "0",
+	}, nil)
+
+	linkStats, err := stats.Stats(context.Background(), "abc")
+	s.Require().NoError(err)
+	s.Assert().Equal(&repository.LinkStats{
+		Total:     3,
+		Days:      map[string]int64{"2021-04-29": 1, "2021-04-30": 2},
+		Referrers: map[string]int64{"direct": 3, "a:b.com:1": 0},
+		Countries: map[string]int64{"unknown": 3},
+	}, linkStats)
+}
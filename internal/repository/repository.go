@@ -0,0 +1,88 @@
+package repository
+
+//go:generate mockgen -source=./repository.go -destination=./mocks/repository.go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrNotFound = errors.New("link not found")
+	ErrDeleted  = errors.New("link deleted")
+	// ErrInvalidCursor is returned by List for a cursor the backend did not hand out
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrVersionMismatch is returned by Update when the link changed since the version it was read at
+	ErrVersionMismatch = errors.New("link version mismatch")
+	// ErrHitsExceeded is returned by IncrementHits once the link was visited MaxHits times
+	ErrHitsExceeded = errors.New("link hit quota exhausted")
+)
+
+// Link is a short code with its destination, expiry and hit counters.
+// Version starts at 1 and is bumped by every Update. PasswordHash is the bcrypt hash
+// of the password protecting the link, empty for public links. RedirectStatus is the status
+// visitors are redirected with, 0 for 302 Found. Owner is the tenant of the API key that created
+// the link, empty for links created with the admin token or before API keys. Flagged is the threat type
+// a reputation check reported for the destination, empty for links that are not flagged.
+type Link struct {
+	Code      string
+	FullURL   string
+	ExpireAt  int64
+	MaxHits   int64
+	Hits      int64
+	CreatedAt int64
+	Version   int64
+
+	PasswordHash   string
+	RedirectStatus int
+	Owner          string
+	Flagged        string
+}
+
+// StatusCode is the status the link redirects with
+func (l Link) StatusCode() int {
+	if l.RedirectStatus == 0 {
+		return http.StatusFound
+	}
+	return l.RedirectStatus
+}
+
+// ListFilter selects a page of links. Cursor is opaque, an empty cursor starts from the beginning.
+// A non-empty Owner only selects the links of that tenant.
+type ListFilter struct {
+	Cursor     string
+	Limit      int
+	CodePrefix string
+	Keyword    string
+	Owner      string
+}
+
+// LinkRepository stores links. Get and IncrementHits return ErrNotFound for unknown codes
+// and ErrDeleted for codes removed through Delete.
+type LinkRepository interface {
+	// Create stores link only when its code is free and reports whether it was stored
+	Create(ctx context.Context, link Link) (bool, error)
+	// CreateBatch creates links in one round trip, created[i] reports whether links[i] was stored
+	CreateBatch(ctx context.Context, links []Link) ([]bool, error)
+	// Update overwrites the destination, expiry and quota of an existing link, its hits, password, redirect status, owner and flag are kept.
+	// A non-zero link.Version only updates a link still at that version, ErrVersionMismatch otherwise.
+	Update(ctx context.Context, link Link) error
+	Get(ctx context.Context, code string) (*Link, error)
+	// Delete removes the link and leaves a tombstone behind
+	Delete(ctx context.Context, code string) error
+	// List returns a page of links and the cursor of the next page, empty when there is none
+	List(ctx context.Context, filter ListFilter) ([]Link, string, error)
+	// IncrementHits atomically counts a visit and returns the new number of hits. Once the link was visited
+	// MaxHits times it returns ErrHitsExceeded without counting, so rejected visits do not skew reporting.
+	IncrementHits(ctx context.Context, code string) (int64, error)
+	// Flag sets the threat type of a link without changing its version, an empty threat clears it
+	Flag(ctx context.Context, code string, threat string) error
+}
+
+// Counter hands out increasing numbers, used by the counter short code generator
+type Counter interface {
+	Incr(ctx context.Context, key string) (int64, error)
+	// IncrBy reserves n numbers at once and returns the last of them
+	IncrBy(ctx context.Context, key string, n int64) (int64, error)
+}
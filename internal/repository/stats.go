@@ -0,0 +1,41 @@
+package repository
+
+//go:generate mockgen -source=./stats.go -destination=./mocks/stats.go
+
+import (
+	"context"
+)
+
+// Click is one successful redirect. Referrer is the referring host, Day the UTC date of Timestamp.
+type Click struct {
+	Code      string
+	Timestamp int64
+	Day       string
+	Referrer  string
+	UserAgent string
+	Country   string
+	IPHash    string
+}
+
+// LinkStats breaks the clicks of a link down by day, referrer and country
+type LinkStats struct {
+	Total     int64            `json:"total"`
+	Days      map[string]int64 `json:"days"`
+	Referrers map[string]int64 `json:"referrers"`
+	Countries map[string]int64 `json:"countries"`
+}
+
+// StatsRepository aggregates clicks per short code
+type StatsRepository interface {
+	RecordClick(ctx context.Context, click Click) error
+	// Stats returns empty stats for a code without clicks
+	Stats(ctx context.Context, code string) (*LinkStats, error)
+}
+
+func NewLinkStats() *LinkStats {
+	return &LinkStats{
+		Days:      map[string]int64{},
+		Referrers: map[string]int64{},
+		Countries: map[string]int64{},
+	}
+}
@@ -0,0 +1,25 @@
+package reputation
+
+import "time"
+
+const (
+	// ActionWarn only logs the match
+	ActionWarn = "warn"
+	// ActionFlag lets links be created, the sweeper flags them
+	ActionFlag = "flag"
+	// ActionRefuse refuses to create links, the sweeper flags the stored ones
+	ActionRefuse = "refuse"
+)
+
+type Config struct {
+	// File is a Safe Browsing threatListUpdates response holding raw hashes, empty disables the checks. Urls
+	// matching a full hash are refused and flagged, urls matching a shorter prefix get PrefixAction.
+	File string
+	// PrefixAction is what happens to urls matching a hash prefix only: warn, flag or refuse. A prefix is
+	// shared by many urls, so warn is the default. Real lists mostly hold 4 byte prefixes, with them the
+	// default only logs matches and refuses nothing.
+	PrefixAction   string
+	ReloadInterval time.Duration
+	// SweepInterval is how often stored links are checked again, 0 never sweeps them
+	SweepInterval time.Duration
+}
@@ -0,0 +1,187 @@
+package reputation
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	maxHostSuffixes = 4
+	maxPathPrefixes = 4
+)
+
+// expressions are the host suffix and path prefix combinations of the canonical form of raw that
+// Safe Browsing hashes, at most 5 hosts times 6 paths
+func expressions(raw string) ([]string, error) {
+	host, path, query, err := canonicalize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var exprs []string
+	seen := make(map[string]bool)
+	for _, h := range hostSuffixes(host) {
+		for _, p := range pathPrefixes(path, query) {
+			if expr := h + p; !seen[expr] {
+				seen[expr] = true
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	return exprs, nil
+}
+
+// canonicalize follows the url canonicalization of Safe Browsing: the url is unescaped until it does
+// not change, the host is lowercased without dots at its ends nor repeated, the path is resolved
+// and the result is escaped again. The scheme, credentials, port and fragment are dropped.
+func canonicalize(raw string) (string, string, string, error) {
+	raw = strings.TrimSpace(strings.NewReplacer("\t", "", "\r", "", "\n", "").Replace(raw))
+	if i := strings.Index(raw, "#"); i >= 0 {
+		raw = raw[:i]
+	}
+	for {
+		unescaped := unescape(raw)
+		if unescaped == raw {
+			break
+		}
+		raw = unescaped
+	}
+
+	if i := strings.Index(raw, "://"); i >= 0 {
+		raw = raw[i+len("://"):]
+	}
+	authority, rest := raw, "/"
+	if i := strings.IndexAny(raw, "/?"); i >= 0 {
+		authority, rest = raw[:i], raw[i:]
+	}
+	path, query := rest, ""
+	if i := strings.Index(rest, "?"); i >= 0 {
+		path, query = rest[:i], rest[i:]
+	}
+
+	host := authority[strings.LastIndex(authority, "@")+1:]
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(strings.ToLower(host), ".[]")
+	for strings.Contains(host, "..") {
+		host = strings.Replace(host, "..", ".", -1)
+	}
+	if host == "" {
+		return "", "", "", fmt.Errorf("url without host: %v", raw)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+
+	return escape(host), escape(cleanPath(path)), escape(query), nil
+}
+
+// hostSuffixes are the exact host and up to 4 hosts formed from its last 5 components,
+// the top-level domain alone is skipped. IP addresses are only looked up exactly.
+func hostSuffixes(host string) []string {
+	hosts := []string{host}
+	if net.ParseIP(host) != nil {
+		return hosts
+	}
+
+	parts := strings.Split(host, ".")
+	start := len(parts) - 1 - maxHostSuffixes
+	if start < 1 {
+		start = 1
+	}
+	for i := start; i < len(parts)-1; i++ {
+		hosts = append(hosts, strings.Join(parts[i:], "."))
+	}
+	return hosts
+}
+
+// pathPrefixes are the exact path with and without query and the first 4 directories from the root
+func pathPrefixes(path string, query string) []string {
+	var paths []string
+	if query != "" {
+		paths = append(paths, path+query)
+	}
+	paths = append(paths, path)
+
+	prefix := "/"
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i := 0; i < maxPathPrefixes; i++ {
+		paths = append(paths, prefix)
+		// the last part is a file, or empty for a path ending with /
+		if i >= len(parts)-1 {
+			break
+		}
+		prefix += parts[i] + "/"
+	}
+	return paths
+}
+
+// cleanPath resolves . and .. segments and collapses repeated slashes, a trailing slash is kept
+func cleanPath(path string) string {
+	var segments []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		switch part {
+		case "", ".":
+			// keep the directory of a trailing . or empty segment
+			if i == len(parts)-1 {
+				segments = append(segments, "")
+			}
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+			if i == len(parts)-1 {
+				segments = append(segments, "")
+			}
+		default:
+			segments = append(segments, part)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// unescape decodes every valid %XX sequence and leaves the others as they are
+func unescape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			builder.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+			continue
+		}
+		builder.WriteByte(s[i])
+	}
+	return builder.String()
+}
+
+// escape encodes control characters, spaces, non-ASCII bytes, # and %
+func escape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= 0x20 || c >= 0x7f || c == '#' || c == '%' {
+			builder.WriteString(fmt.Sprintf("%%%02X", c))
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,239 @@
+package reputation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var consoleLog zerolog.Logger
+
+// Checker looks destination urls up in a reputation source
+type Checker interface {
+	// Check returns the verdict of the source on fullURL, a zero Verdict when it is not listed
+	Check(fullURL string) (Verdict, error)
+}
+
+// Verdict is what a reputation source reports about a url
+type Verdict struct {
+	// Threat is the threat type the url is listed for, empty when it is not listed
+	Threat string
+	// Confirmed is set when the full hash of one of the expressions of the url is listed. A match on
+	// a shorter prefix alone may be another url sharing the prefix.
+	Confirmed bool
+	// Action is what to do with the url, ActionRefuse when confirmed and the configured PrefixAction otherwise
+	Action string
+}
+
+// Refused reports whether links to the url are refused
+func (v Verdict) Refused() bool {
+	return v.Action == ActionRefuse
+}
+
+// Flagged reports whether stored links to the url are flagged
+func (v Verdict) Flagged() bool {
+	return v.Action == ActionRefuse || v.Action == ActionFlag
+}
+
+// List is a Checker reading a local copy of Safe Browsing threat lists. The file is the JSON response
+// of threatListUpdates:fetch with full updates and raw hashes, the prefixes of every list are merged.
+// Full hashes are additions with a prefix size of 32. The list does not call fullHashes:find, a url
+// is confirmed only when the file holds the full hash of one of its expressions, other matches get
+// the prefix action.
+type List struct {
+	mu           sync.RWMutex
+	prefixes     map[string]string
+	sizes        []int
+	file         string
+	modTime      time.Time
+	prefixAction string
+}
+
+func NewList(config Config) (*List, error) {
+	prefixAction := config.PrefixAction
+	switch prefixAction {
+	case "":
+		prefixAction = ActionWarn
+	case ActionWarn, ActionFlag, ActionRefuse:
+	default:
+		return nil, fmt.Errorf("unknown reputation prefix action %q", config.PrefixAction)
+	}
+
+	list := &List{file: config.File, prefixAction: prefixAction}
+	if err := list.Reload(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Check implements Checker, a confirmed match wins over a prefix match of another expression
+func (l *List) Check(fullURL string) (Verdict, error) {
+	exprs, err := expressions(fullURL)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var verdict Verdict
+	for _, expr := range exprs {
+		sum := sha256.Sum256([]byte(expr))
+		for _, size := range l.sizes {
+			threat, ok := l.prefixes[string(sum[:size])]
+			if !ok {
+				continue
+			}
+			if size == sha256.Size {
+				return Verdict{Threat: threat, Confirmed: true, Action: ActionRefuse}, nil
+			}
+			if verdict.Threat == "" {
+				verdict = Verdict{Threat: threat, Action: l.prefixAction}
+			}
+		}
+	}
+	return verdict, nil
+}
+
+// Len is the number of hash prefixes loaded
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.prefixes)
+}
+
+// Reload replaces the prefixes with those of the file
+func (l *List) Reload() error {
+	info, err := os.Stat(l.file)
+	if err != nil {
+		return err
+	}
+
+	prefixes, sizes, err := readFile(l.file)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prefixes = prefixes
+	l.sizes = sizes
+	l.modTime = info.ModTime()
+	return nil
+}
+
+// Watch polls the file every interval and reloads it when it changed, until ctx is done
+func (l *List) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(l.file)
+			if err != nil {
+				consoleLog.Warn().Msgf("Unexpected error to stat reputation file: %v, err: %v", l.file, err)
+				continue
+			}
+
+			l.mu.RLock()
+			changed := !info.ModTime().Equal(l.modTime)
+			l.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			// keep the previous prefixes when the new file is broken
+			if err := l.Reload(); err != nil {
+				consoleLog.Warn().Msgf("Unexpected error to reload reputation file: %v, err: %v", l.file, err)
+				continue
+			}
+			consoleLog.Info().Msgf("Reloaded reputation file: %v", l.file)
+		}
+	}
+}
+
+// listUpdates is the part of a threatListUpdates:fetch response the list is read from
+type listUpdates struct {
+	ListUpdateResponses []struct {
+		ThreatType   string `json:"threatType"`
+		ResponseType string `json:"responseType"`
+		Additions    []struct {
+			CompressionType string `json:"compressionType"`
+			RawHashes       struct {
+				PrefixSize int    `json:"prefixSize"`
+				RawHashes  string `json:"rawHashes"`
+			} `json:"rawHashes"`
+		} `json:"additions"`
+	} `json:"listUpdateResponses"`
+}
+
+// readFile returns the threat type of every prefix and the prefix sizes, shortest first
+func readFile(path string) (map[string]string, []int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var updates listUpdates
+	if err = json.Unmarshal(data, &updates); err != nil {
+		return nil, nil, fmt.Errorf("invalid reputation file %v: %v", path, err)
+	}
+
+	prefixes := make(map[string]string)
+	var sizes []int
+	for _, update := range updates.ListUpdateResponses {
+		// a partial update removes prefixes of a list the file does not hold
+		if update.ResponseType == "PARTIAL_UPDATE" {
+			return nil, nil, fmt.Errorf("reputation file %v holds a partial update of %v", path, update.ThreatType)
+		}
+		for _, addition := range update.Additions {
+			if addition.CompressionType != "" && addition.CompressionType != "RAW" {
+				return nil, nil, fmt.Errorf("reputation file %v: %v compression is not supported", path, addition.CompressionType)
+			}
+			size := addition.RawHashes.PrefixSize
+			if size < 4 || size > sha256.Size {
+				return nil, nil, fmt.Errorf("reputation file %v: invalid prefix size %v", path, size)
+			}
+			raw, err := base64.StdEncoding.DecodeString(addition.RawHashes.RawHashes)
+			if err != nil || len(raw)%size != 0 {
+				return nil, nil, fmt.Errorf("reputation file %v: invalid raw hashes of %v", path, update.ThreatType)
+			}
+
+			for i := 0; i < len(raw); i += size {
+				prefix := string(raw[i : i+size])
+				if _, ok := prefixes[prefix]; !ok {
+					prefixes[prefix] = update.ThreatType
+				}
+			}
+			sizes = addSize(sizes, size)
+		}
+	}
+	return prefixes, sizes, nil
+}
+
+func addSize(sizes []int, size int) []int {
+	for i, known := range sizes {
+		if known == size {
+			return sizes
+		}
+		if known > size {
+			return append(sizes[:i], append([]int{size}, sizes[i:]...)...)
+		}
+	}
+	return append(sizes, size)
+}
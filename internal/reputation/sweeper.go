@@ -0,0 +1,97 @@
+package reputation
+
+import (
+	"context"
+	"time"
+	"url-shortener/internal/repository"
+)
+
+const sweepPageSize = 100
+
+// Sweeper checks the stored links again so links whose destination turned malicious after they were
+// created get flagged, and flagged links whose destination is no longer listed are cleared. Verdicts
+// whose action is to flag or refuse flag a link, the others are logged.
+type Sweeper struct {
+	links    repository.LinkRepository
+	checker  Checker
+	interval time.Duration
+}
+
+func NewSweeper(links repository.LinkRepository, checker Checker, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		links:    links,
+		checker:  checker,
+		interval: interval,
+	}
+}
+
+// Run sweeps every interval until ctx is done
+func (s *Sweeper) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flagged, err := s.Sweep(ctx)
+			if err != nil {
+				consoleLog.Warn().Msgf("Unexpected error to sweep links: %v", err)
+				continue
+			}
+			consoleLog.Info().Msgf("Swept links, %v flagged", flagged)
+		}
+	}
+}
+
+// Sweep checks every stored link once, updates the links whose verdict changed and returns
+// the number of flagged links. Links the checker fails on keep their flag.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	flagged := 0
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return flagged, err
+		}
+
+		links, next, err := s.links.List(ctx, repository.ListFilter{Cursor: cursor, Limit: sweepPageSize})
+		if err != nil {
+			return flagged, err
+		}
+
+		for _, link := range links {
+			verdict, err := s.checker.Check(link.FullURL)
+			threat := ""
+			switch {
+			case err != nil:
+				consoleLog.Warn().Msgf("Unexpected error to check link: %v, err: %v", link.Code, err)
+				threat = link.Flagged
+			case verdict.Flagged():
+				threat = verdict.Threat
+			case verdict.Threat != "":
+				consoleLog.Warn().Msgf("Link %v matches a %v hash prefix, not flagged by the prefix action", link.Code, verdict.Threat)
+			}
+			if threat != "" {
+				flagged++
+			}
+			if threat == link.Flagged {
+				continue
+			}
+
+			// the link may have been deleted since it was listed
+			if err = s.links.Flag(ctx, link.Code, threat); err != nil && err != repository.ErrNotFound {
+				return flagged, err
+			}
+		}
+
+		if next == "" {
+			return flagged, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,21 @@
+package tracing
+
+const (
+	// ExporterLog logs every ended span
+	ExporterLog = "log"
+	// ExporterNewRelic reports spans to New Relic, requests as transactions and calls as segments
+	ExporterNewRelic = "newrelic"
+)
+
+type Config struct {
+	// Exporter is where spans go, log or newrelic. Empty only propagates trace context.
+	Exporter string
+	// ServiceName is the name of the service in exported spans
+	ServiceName string
+	NewRelic    NewRelicConfig
+}
+
+type NewRelicConfig struct {
+	AppName string
+	License string
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// New returns the tracer provider exporting spans to the exporter of config. Spans continue the trace of
+// a sampled caller, traces started by the service are always sampled.
+func New(config Config) (*sdktrace.TracerProvider, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(config.ServiceName))),
+	}
+
+	switch config.Exporter {
+	case "":
+	case ExporterLog:
+		options = append(options, sdktrace.WithSyncer(NewLogExporter(config.ServiceName)))
+	case ExporterNewRelic:
+		appName := config.NewRelic.AppName
+		if appName == "" {
+			appName = config.ServiceName
+		}
+		app, err := newrelic.NewApplication(
+			newrelic.ConfigAppName(appName),
+			newrelic.ConfigLicense(config.NewRelic.License),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("new relic exporter (%v)", err)
+		}
+		options = append(options, sdktrace.WithSpanProcessor(NewNewRelic(app)))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// LogExporter logs every ended span with the ids tying it to its trace
+type LogExporter struct {
+	service string
+}
+
+func NewLogExporter(service string) *LogExporter {
+	return &LogExporter{service: service}
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (e *LogExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		sc := span.SpanContext()
+		event := log.Info().
+			Str("service", e.service).
+			Str("trace_id", sc.TraceID().String()).
+			Str("span_id", sc.SpanID().String())
+		if parent := span.Parent(); parent.IsValid() {
+			event = event.Str("parent_id", parent.SpanID().String())
+		}
+		attributes := map[string]interface{}{}
+		for _, attribute := range span.Attributes() {
+			attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+		}
+		event = event.
+			Str("kind", span.SpanKind().String()).
+			Dur("duration", span.EndTime().Sub(span.StartTime())).
+			Interface("attributes", attributes)
+		if status := span.Status(); status.Code == codes.Error {
+			event = event.Str("error", status.Description)
+		}
+		event.Msg(span.Name())
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter, spans are logged as they end
+func (e *LogExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
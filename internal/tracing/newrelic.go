@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// datastoreProducts are the New Relic products of the db.system values
+var datastoreProducts = map[string]newrelic.DatastoreProduct{
+	"redis":      newrelic.DatastoreRedis,
+	"postgresql": newrelic.DatastorePostgres,
+	"sqlite":     newrelic.DatastoreSQLite,
+}
+
+// NewRelic is a span processor reporting spans to New Relic as they happen. The first span of a trace
+// in the service is a transaction, client spans are datastore segments and other spans plain segments of it.
+type NewRelic struct {
+	app *newrelic.Application
+
+	mu    sync.Mutex
+	spans map[trace.SpanID]*relicSpan
+}
+
+// relicSpan is what a span started in New Relic, one of segment and datastore is set for non root spans
+type relicSpan struct {
+	txn       *newrelic.Transaction
+	segment   *newrelic.Segment
+	datastore *newrelic.DatastoreSegment
+}
+
+func NewNewRelic(app *newrelic.Application) *NewRelic {
+	return &NewRelic{app: app, spans: map[trace.SpanID]*relicSpan{}}
+}
+
+// OnStart implements sdktrace.SpanProcessor
+func (n *NewRelic) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	parent, ok := n.spans[span.Parent().SpanID()]
+	if !ok {
+		n.spans[span.SpanContext().SpanID()] = &relicSpan{txn: n.app.StartTransaction(span.Name())}
+		return
+	}
+
+	started := &relicSpan{txn: parent.txn}
+	if span.SpanKind() == trace.SpanKindClient {
+		// the datastore is known once the attributes are set
+		started.datastore = &newrelic.DatastoreSegment{StartTime: parent.txn.StartSegmentNow()}
+	} else {
+		started.segment = parent.txn.StartSegment(span.Name())
+	}
+	n.spans[span.SpanContext().SpanID()] = started
+}
+
+// OnEnd implements sdktrace.SpanProcessor
+func (n *NewRelic) OnEnd(span sdktrace.ReadOnlySpan) {
+	n.mu.Lock()
+	started, ok := n.spans[span.SpanContext().SpanID()]
+	delete(n.spans, span.SpanContext().SpanID())
+	n.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	switch {
+	case started.datastore != nil:
+		started.datastore.Product = datastoreProducts[attributes[semconv.DBSystemKey].AsString()]
+		started.datastore.Operation = attributes[semconv.DBOperationKey].AsString()
+		started.datastore.Collection = attributes[semconv.DBSQLTableKey].AsString()
+		started.datastore.ParameterizedQuery = attributes[semconv.DBStatementKey].AsString()
+		started.datastore.End()
+	case started.segment != nil:
+		started.segment.End()
+	default:
+		n.endTransaction(started.txn, span, attributes)
+	}
+}
+
+// endTransaction ends the transaction of the root span, server spans are reported as web transactions
+func (n *NewRelic) endTransaction(txn *newrelic.Transaction, span sdktrace.ReadOnlySpan, attributes map[attribute.Key]attribute.Value) {
+	if span.SpanKind() == trace.SpanKindServer {
+		txn.SetWebRequest(newrelic.WebRequest{
+			Method:    attributes[semconv.HTTPMethodKey].AsString(),
+			URL:       &url.URL{Path: attributes[semconv.HTTPTargetKey].AsString()},
+			Transport: newrelic.TransportHTTP,
+		})
+		if status, ok := attributes[semconv.HTTPStatusCodeKey]; ok {
+			txn.SetWebResponse(nil).WriteHeader(int(status.AsInt64()))
+		}
+	}
+	for key, value := range attributes {
+		txn.AddAttribute(string(key), value.AsInterface())
+	}
+	txn.AddAttribute("trace.id", span.SpanContext().TraceID().String())
+	if status := span.Status(); status.Code == codes.Error {
+		txn.NoticeError(errors.New(status.Description))
+	}
+	txn.End()
+}
+
+// Shutdown implements sdktrace.SpanProcessor, New Relic is given until the deadline of ctx to send
+// what it holds, 10 seconds without one
+func (n *NewRelic) Shutdown(ctx context.Context) error {
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	n.app.Shutdown(timeout)
+	return nil
+}
+
+// ForceFlush implements sdktrace.SpanProcessor, New Relic harvests on its own schedule
+func (n *NewRelic) ForceFlush(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/propagation"
+	"net/http"
+)
+
+// Propagator reads and writes the W3C traceparent and tracestate headers
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Extract returns ctx carrying the remote span context of the traceparent header, ctx as is without a valid one
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return Propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject writes the span context of ctx to header so the callee continues the trace
+func Inject(ctx context.Context, header http.Header) {
+	Propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
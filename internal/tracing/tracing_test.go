@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	ctx, root := provider.Tracer(Instrumentation).Start(context.Background(), "GET /{code}", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := Start(ctx, "GET", trace.SpanKindClient, semconv.DBSystemRedis)
+	Fail(child, errors.New("connection refused"))
+	Fail(child, nil)
+	child.End()
+	root.End()
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, root.SpanContext().TraceID(), ended[0].SpanContext().TraceID())
+	assert.Equal(t, root.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, ended[0].SpanKind())
+	assert.Contains(t, ended[0].Attributes(), semconv.DBSystemRedis)
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "connection refused"}, ended[0].Status())
+}
+
+func TestStart_WithoutSpan(t *testing.T) {
+	ctx, span := Start(context.Background(), "GET", trace.SpanKindClient)
+	assert.Equal(t, context.Background(), ctx)
+	assert.False(t, span.IsRecording())
+	assert.False(t, span.SpanContext().IsValid())
+
+	// a non recording span ignores every call
+	Fail(span, errors.New("ignored"))
+	span.End()
+}
+
+func TestPropagation(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer(Instrumentation)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set("tracestate", "congo=t61rcWkgMzE")
+	ctx, span := tracer.Start(Extract(context.Background(), header), "GET /{code}")
+	span.End()
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "congo=t61rcWkgMzE", span.SpanContext().TraceState().String())
+
+	out := http.Header{}
+	Inject(ctx, out)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String()+"-01", out.Get("traceparent"))
+	assert.Equal(t, "congo=t61rcWkgMzE", out.Get("tracestate"))
+
+	// the caller decided not to sample the trace, its spans are only propagated
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx, span = tracer.Start(Extract(context.Background(), header), "GET /{code}")
+	span.End()
+	assert.False(t, span.SpanContext().IsSampled())
+	assert.Len(t, spans.Ended(), 1)
+	Inject(ctx, out)
+	assert.True(t, strings.HasSuffix(out.Get("traceparent"), "-00"))
+
+	// a broken traceparent starts a new trace
+	header.Set("traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	assert.False(t, trace.SpanContextFromContext(Extract(context.Background(), header)).IsValid())
+}
+
+func TestNew(t *testing.T) {
+	provider, err := New(Config{})
+	require.NoError(t, err)
+	assert.NotNil(t, provider)
+
+	provider, err = New(Config{Exporter: ExporterLog, ServiceName: "url-shortener"})
+	require.NoError(t, err)
+	assert.NoError(t, provider.Shutdown(context.Background()))
+
+	_, err = New(Config{Exporter: ExporterNewRelic, ServiceName: "url-shortener"})
+	assert.Error(t, err, "a license is required")
+
+	_, err = New(Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestNewRelic(t *testing.T) {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("url-shortener"),
+		newrelic.ConfigLicense(strings.Repeat("0", 40)),
+		newrelic.ConfigEnabled(false),
+	)
+	require.NoError(t, err)
+	relic := NewNewRelic(app)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(relic)).Tracer(Instrumentation)
+
+	ctx, root := tracer.Start(context.Background(), "GET /{code}", trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(http.MethodGet), semconv.HTTPTargetKey.String("/abc")))
+	root.SetAttributes(semconv.HTTPStatusCodeKey.Int(http.StatusFound))
+	_, get := Start(ctx, "GET", trace.SpanKindClient, semconv.DBSystemRedis, semconv.DBOperationKey.String("GET"))
+	_, work := Start(ctx, "render", trace.SpanKindInternal)
+
+	assert.Len(t, relic.spans, 3)
+	assert.NotNil(t, relic.spans[get.SpanContext().SpanID()].datastore)
+	assert.NotNil(t, relic.spans[work.SpanContext().SpanID()].segment)
+
+	work.End()
+	get.End()
+	Fail(root, errors.New("503 Service Unavailable"))
+	root.End()
+	assert.Empty(t, relic.spans)
+}
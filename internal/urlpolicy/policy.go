@@ -0,0 +1,259 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules a destination url can violate
+const (
+	RuleInvalid = "invalid"
+	RuleScheme  = "scheme"
+	RulePrivate = "private"
+	RuleOwnHost = "own-host"
+)
+
+const lookupTimeout = 2 * time.Second
+
+// privateNetworks are the ranges a public destination never points into
+var privateNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	// NAT64 gateways translate the IPv4 address in the last 32 bits
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+// defaultPolicy applies to a nil *Policy
+var defaultPolicy = New(Config{})
+
+// Config of the destinations links may point to
+type Config struct {
+	// Schemes links may use, http and https when empty
+	Schemes []string
+	// AllowPrivate lets links point to loopback, private and link-local hosts
+	AllowPrivate bool
+	// Resolve looks host names up and rejects those resolving to private addresses
+	Resolve bool
+	// Hosts the service is reached on besides its domains, links to them would redirect to short links
+	Hosts []string
+}
+
+// Violation is the rule a url breaks, Detail is the offending part of the url
+type Violation struct {
+	Rule   string
+	Detail string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("url breaks the %v rule (%v)", v.Rule, v.Detail)
+}
+
+// Policy normalizes destination urls and rejects those visitors should not be redirected to
+type Policy struct {
+	schemes      map[string]bool
+	allowPrivate bool
+	resolve      bool
+	hosts        map[string]bool
+	lookup       func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// New creates the policy of config, ownHosts are the hosts of the configured domains
+func New(config Config, ownHosts ...string) *Policy {
+	schemes := config.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+
+	p := &Policy{
+		schemes:      make(map[string]bool, len(schemes)),
+		allowPrivate: config.AllowPrivate,
+		resolve:      config.Resolve,
+		hosts:        make(map[string]bool, len(config.Hosts)+len(ownHosts)),
+		lookup:       net.DefaultResolver.LookupIPAddr,
+	}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = true
+	}
+	for _, hosts := range [][]string{config.Hosts, ownHosts} {
+		for _, host := range hosts {
+			p.hosts[hostname(host)] = true
+		}
+	}
+	return p
+}
+
+// Check normalizes raw and returns it when it follows the policy, a *Violation otherwise.
+// Host names are turned into punycode, default ports and fragments are dropped and urls without
+// scheme get http. ownHosts are hosts of the service in addition to those of the policy.
+func (p *Policy) Check(raw string, ownHosts ...string) (string, error) {
+	if p == nil {
+		p = defaultPolicy
+	}
+
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err == nil && u.Scheme == "" {
+		u, err = url.Parse("http://" + strings.TrimSpace(raw))
+	}
+	if err != nil {
+		return "", &Violation{Rule: RuleInvalid, Detail: raw}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !p.schemes[u.Scheme] {
+		return "", &Violation{Rule: RuleScheme, Detail: u.Scheme}
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return "", &Violation{Rule: RuleInvalid, Detail: raw}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if net.ParseIP(host) == nil {
+		if host, err = idna.Lookup.ToASCII(host); err != nil {
+			return "", &Violation{Rule: RuleInvalid, Detail: u.Hostname()}
+		}
+	}
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = joinHost(host, port)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if p.hosts[host] || contains(ownHosts, host) {
+		return "", &Violation{Rule: RuleOwnHost, Detail: host}
+	}
+	if !p.allowPrivate && p.private(host) {
+		return "", &Violation{Rule: RulePrivate, Detail: host}
+	}
+	return u.String(), nil
+}
+
+// private reports whether host is local or an address of a private network, host names only
+// when the policy resolves them
+func (p *Policy) private(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if ip := parseIP(host); ip != nil {
+		return privateIP(ip)
+	}
+	if !p.resolve {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	// hosts that do not resolve are left to the visitors, they can not reach anything either
+	addrs, _ := p.lookup(ctx, host)
+	for _, addr := range addrs {
+		if privateIP(addr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func privateIP(ip net.IP) bool {
+	if ip.IsMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP parses IP literals, including the decimal, octal and hex IPv4 forms browsers accept
+// such as 2130706433 or 0x7f.1
+func parseIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return nil
+		}
+		values[i] = value
+	}
+
+	// the last part fills the bytes the others leave
+	last := values[len(values)-1]
+	if last >= 1<<(8*uint(5-len(values))) {
+		return nil
+	}
+	address := last
+	for i, value := range values[:len(values)-1] {
+		if value > 255 {
+			return nil
+		}
+		address |= value << (8 * uint(3-i))
+	}
+	return net.IPv4(byte(address>>24), byte(address>>16), byte(address>>8), byte(address))
+}
+
+func joinHost(host string, port string) string {
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port == "" {
+		return host
+	}
+	return host + ":" + port
+}
+
+// hostname lowercases host and strips its port
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func contains(hosts []string, host string) bool {
+	for _, own := range hosts {
+		if hostname(own) == host {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,102 @@
+package urlpolicy
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestCheck_Normalizes(t *testing.T) {
+	p := New(Config{})
+
+	cases := map[string]string{
+		"https://www.speedtest.net":             "https://www.speedtest.net",
+		"HTTPS://WWW.SpeedTest.NET:443/Run#top": "https://www.speedtest.net/Run",
+		"http://www.speedtest.net:80/?q=1":      "http://www.speedtest.net/?q=1",
+		"http://www.speedtest.net:8080/":        "http://www.speedtest.net:8080/",
+		"www.speedtest.net/run":                 "http://www.speedtest.net/run",
+		"https://bücher.example/":               "https://xn--bcher-kva.example/",
+		"https://www.speedtest.net./":           "https://www.speedtest.net/",
+		"http://[2001:db8::1]:80/":              "http://[2001:db8::1]/",
+	}
+	for raw, want := range cases {
+		got, err := p.Check(raw)
+		assert.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+}
+
+func TestCheck_Violations(t *testing.T) {
+	p := New(Config{Hosts: []string{"sho.rt:3000"}}, "go.example.com")
+
+	cases := map[string]string{
+		"javascript:alert(1)":                RuleScheme,
+		"data:text/html;base64,PHNjcmlwdD4=": RuleScheme,
+		"ftp://files.example.com/":           RuleScheme,
+		"http://localhost:3000/":             RulePrivate,
+		"http://api.localhost/":              RulePrivate,
+		"http://127.0.0.1/":                  RulePrivate,
+		"http://2130706433/":                 RulePrivate,
+		"http://0x7f.1/":                     RulePrivate,
+		"http://10.1.2.3/":                   RulePrivate,
+		"http://172.16.0.1/":                 RulePrivate,
+		"http://192.168.1.1/":                RulePrivate,
+		"http://192.0.0.8/":                  RulePrivate,
+		"http://198.18.0.1/":                 RulePrivate,
+		"http://198.19.255.1/":               RulePrivate,
+		"http://240.0.0.1/":                  RulePrivate,
+		"http://255.255.255.255/":            RulePrivate,
+		"http://[64:ff9b::7f00:1]/":          RulePrivate,
+		"http://169.254.169.254/latest/":     RulePrivate,
+		"http://[::1]/":                      RulePrivate,
+		"http://[fe80::1]/":                  RulePrivate,
+		"http://[::ffff:127.0.0.1]/":         RulePrivate,
+		"https://go.example.com/abc":         RuleOwnHost,
+		"https://SHO.RT/abc":                 RuleOwnHost,
+		"http://":                            RuleInvalid,
+		"http://exa mple.com/":               RuleInvalid,
+		"https://www.speedtest.net/%zz":      RuleInvalid,
+	}
+	for raw, rule := range cases {
+		_, err := p.Check(raw)
+		if assert.IsType(t, &Violation{}, err, raw) {
+			assert.Equal(t, rule, err.(*Violation).Rule, raw)
+		}
+	}
+
+	// hosts of the request are the service too
+	_, err := p.Check("https://links.example.org/abc", "links.example.org:8080")
+	assert.Equal(t, RuleOwnHost, err.(*Violation).Rule)
+}
+
+func TestCheck_Config(t *testing.T) {
+	p := New(Config{Schemes: []string{"https"}, AllowPrivate: true})
+
+	_, err := p.Check("http://www.speedtest.net")
+	assert.Equal(t, RuleScheme, err.(*Violation).Rule)
+	got, err := p.Check("https://192.168.1.1/admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://192.168.1.1/admin", got)
+}
+
+func TestCheck_Resolve(t *testing.T) {
+	p := New(Config{Resolve: true})
+	p.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.7")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+
+	_, err := p.Check("https://internal.example.com/")
+	assert.Equal(t, RulePrivate, err.(*Violation).Rule)
+	_, err = p.Check("https://www.example.com/")
+	assert.NoError(t, err)
+}
+
+func TestCheck_NilPolicy(t *testing.T) {
+	var p *Policy
+	_, err := p.Check("http://127.0.0.1/")
+	assert.Equal(t, RulePrivate, err.(*Violation).Rule)
+}